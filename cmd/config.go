@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/willgeorgetaylor/junit-reducer/internal/config"
+)
+
+var configPath string
+
+// loadConfig fills in any flags not given on the command line from
+// JUNIT_REDUCER_* environment variables and the config file.
+func loadConfig(cmd *cobra.Command, args []string) error {
+	// Flags have parsed by now, so errors from here on aren't usage errors.
+	cmd.SilenceUsage = true
+
+	path := configPath
+	if path == "" {
+		path = config.Discover(".")
+	}
+
+	values := config.Values{}
+	if path != "" {
+		loaded, err := config.Load(path)
+		if err != nil {
+			return err
+		}
+		if unknown := loaded.UnknownKeys(knownConfigKeys(cmd.Root())); len(unknown) > 0 {
+			return fmt.Errorf("unknown keys in config file %s: %s", path, strings.Join(unknown, ", "))
+		}
+		values = loaded.Section(cmd.Name())
+	}
+
+	return config.Apply(cmd.Flags(), values, os.LookupEnv)
+}
+
+// knownConfigKeys are the flag and subcommand names across the command tree.
+// The config flag itself can't be set from a config file.
+func knownConfigKeys(root *cobra.Command) map[string]bool {
+	known := make(map[string]bool)
+	var visit func(cmd *cobra.Command)
+	visit = func(cmd *cobra.Command) {
+		known[cmd.Name()] = true
+		cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+			known[flag.Name] = true
+		})
+		cmd.PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+			known[flag.Name] = true
+		})
+		for _, child := range cmd.Commands() {
+			visit(child)
+		}
+	}
+	visit(root)
+	delete(known, "config")
+	delete(known, "help")
+	return known
+}
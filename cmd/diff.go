@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/differ"
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+	"github.com/willgeorgetaylor/junit-reducer/internal/reducer"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Used for flags.
+	diffSuitesByString string
+	diffCasesByString  string
+	maxSlowdownPercent float64
+	maxSlowdownSeconds float64
+	diffFormatString   string
+)
+
+// diffCmd compares the timings of two reduced report sets
+var diffCmd = &cobra.Command{
+	Use:   "diff BASE_DIR HEAD_DIR",
+	Short: "Compares the test timings of two reduced report sets",
+	Long:  `Diff matches the suites and cases of two report directories by the same keys used to reduce them, and reports added and removed tests and time deltas. It exits with an error when any suite slows down beyond the given limits, for use as a performance regression gate.`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		suitesBy, ok := enums.TestSuiteFieldValues[diffSuitesByString]
+		if !ok {
+			return errors.New(invalidSelectionMessage("reduce-suites-by", diffSuitesByString, enums.GetTestSuiteFields()))
+		}
+
+		casesBy, ok := enums.TestCaseFieldValues[diffCasesByString]
+		if !ok {
+			return errors.New(invalidSelectionMessage("reduce-cases-by", diffCasesByString, enums.GetTestCaseFields()))
+		}
+
+		format, ok := enums.ReportFormatValues[diffFormatString]
+		if !ok {
+			return errors.New(invalidSelectionMessage("format", diffFormatString, enums.GetReportFormats()))
+		}
+
+		// Keep stdout clean for the diff report.
+		helpers.SetMessageWriter(os.Stderr)
+
+		base, err := loadReportDirectory(args[0])
+		if err != nil {
+			return err
+		}
+
+		head, err := loadReportDirectory(args[1])
+		if err != nil {
+			return err
+		}
+
+		thresholds := differ.Thresholds{
+			MaxSlowdownPercent: maxSlowdownPercent,
+			MaxSlowdownSeconds: maxSlowdownSeconds,
+		}
+		report := differ.Compare(base, head, suitesBy, casesBy, thresholds)
+
+		err = differ.Write(os.Stdout, report, format)
+		if err != nil {
+			return err
+		}
+
+		if report.Summary.Regressed > 0 {
+			return fmt.Errorf("%d test suites slowed down beyond the limit", report.Summary.Regressed)
+		}
+		return nil
+	},
+}
+
+// reportExtensions are the file extensions read from report directories, by
+// input format.
+var reportExtensions = map[enums.InputFormat]string{
+	enums.InputFormatAuto:     "{xml,json,trx}",
+	enums.InputFormatJUnit:    "xml",
+	enums.InputFormatJSON:     "json",
+	enums.InputFormatGoTest:   "json",
+	enums.InputFormatTRX:      "trx",
+	enums.InputFormatNUnit:    "xml",
+	enums.InputFormatXUnit:    "xml",
+	enums.InputFormatCucumber: "json",
+	enums.InputFormatCTRF:     "json",
+}
+
+func loadReportDirectory(dir string) ([]serialization.TestSuite, error) {
+	if !helpers.DirExists(dir) {
+		return nil, fmt.Errorf("report directory '%s' does not exist", dir)
+	}
+	return reducer.LoadReports(reducer.ReportInput{
+		IncludeFilePatterns: []string{filepath.Join(dir, "**", "*."+reportExtensions[inputFormat])},
+		ExcludeFilePatterns: exclude,
+		InputFormat:         inputFormat,
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVar(&diffSuitesByString, "reduce-suites-by", enums.TestSuiteFieldKeys[enums.TestSuiteFieldNameFilepath], fmt.Sprintf("Key to match test suites by. Options: %s", joinOptionsString(enums.GetTestSuiteFields())))
+	diffCmd.Flags().StringVar(&diffCasesByString, "reduce-cases-by", enums.TestCaseFieldKeys[enums.TestCaseFieldName], fmt.Sprintf("Key to match test cases by. Options: %s", joinOptionsString(enums.GetTestCaseFields())))
+	diffCmd.Flags().Float64Var(&maxSlowdownPercent, "max-slowdown-percent", 0, "Fail when a suite slows down by more than this percentage (0 disables)")
+	diffCmd.Flags().Float64Var(&maxSlowdownSeconds, "max-slowdown-seconds", 0, "Fail when a suite slows down by more than this many seconds (0 disables). When combined with --max-slowdown-percent, both must be exceeded")
+	diffCmd.Flags().StringVar(&diffFormatString, "format", enums.ReportFormatKeys[enums.ReportFormatText], fmt.Sprintf("Output format of the diff. Options: %s", joinOptionsString(enums.GetReportFormats())))
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+	"github.com/willgeorgetaylor/junit-reducer/internal/merger"
+	"github.com/willgeorgetaylor/junit-reducer/internal/reducer"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Used for flags.
+	mergeSuitesByString string
+	onConflictString    string
+	mergeOutputFile     string
+)
+
+// mergeCmd combines the reports of a single run into one document
+var mergeCmd = &cobra.Command{
+	Use:   "merge",
+	Short: "Combines JUnit reports into a single report without reducing them",
+	Long:  `Merge concatenates the suites of the included reports into a single JUnit XML document, such as the shards of one CI run before they are reduced. Duplicate suites are resolved by --on-conflict, and the root totals are recomputed from the merged suites.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		suitesBy, ok := enums.TestSuiteFieldValues[mergeSuitesByString]
+		if !ok {
+			return errors.New(invalidSelectionMessage("reduce-suites-by", mergeSuitesByString, enums.GetTestSuiteFields()))
+		}
+
+		resolution, ok := enums.ConflictResolutionValues[onConflictString]
+		if !ok {
+			return errors.New(invalidSelectionMessage("on-conflict", onConflictString, enums.GetConflictResolutions()))
+		}
+
+		if mergeOutputFile == reducer.StdoutPath {
+			// Keep stdout clean for the merged report.
+			helpers.SetMessageWriter(os.Stderr)
+		}
+
+		testSuites, err := reducer.LoadReports(reportInput(cmd))
+		if err != nil {
+			return err
+		}
+
+		document, err := merger.Merge(testSuites, suitesBy, resolution)
+		if err != nil {
+			return err
+		}
+
+		if mergeOutputFile == reducer.StdoutPath {
+			return serialization.WriteDocument(os.Stdout, document)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(mergeOutputFile), 0755); err != nil {
+			helpers.FatalMsg("failed to create output directory: %v", err)
+			return err
+		}
+		file, err := os.Create(mergeOutputFile)
+		if err != nil {
+			helpers.FatalMsg("failed to create merged report: %v", err)
+			return err
+		}
+
+		helpers.PrintMsg("serializing junit xml: %v\n", mergeOutputFile)
+		err = serialization.WriteDocument(file, document)
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			helpers.FatalMsg("failed to close merged report: %v", closeErr)
+			return closeErr
+		}
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+
+	mergeCmd.Flags().StringVar(&mergeSuitesByString, "reduce-suites-by", enums.TestSuiteFieldKeys[enums.TestSuiteFieldNameFilepath], fmt.Sprintf("Key that identifies duplicate test suites. Options: %s", joinOptionsString(enums.GetTestSuiteFields())))
+	mergeCmd.Flags().StringVar(&onConflictString, "on-conflict", enums.ConflictResolutionKeys[enums.ConflictResolutionError], fmt.Sprintf("How to resolve test suites that appear in more than one report. Options: %s", joinOptionsString(enums.GetConflictResolutions())))
+	mergeCmd.Flags().StringVar(&mergeOutputFile, "output-file", "./merged.xml", "Output file for the merged JUnit XML report, or \"-\" to write it to stdout")
+}
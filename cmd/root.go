@@ -4,10 +4,17 @@ Copyright © 2023 Will Taylor
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/willgeorgetaylor/junit-reducer/internal/config"
 	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/flaky"
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+	"github.com/willgeorgetaylor/junit-reducer/internal/quarantine"
 	"github.com/willgeorgetaylor/junit-reducer/internal/reducer"
 
 	"github.com/spf13/cobra"
@@ -15,9 +22,15 @@ import (
 
 var (
 	// Used for flags.
-	include                             string
-	exclude                             string
+	include                             []string
+	exclude                             []string
+	filesFrom                           string
+	readReportsFromStdin                bool
+	inputFormatString                   string
+	inputFormat                         enums.InputFormat
 	outputPath                          string
+	outputFormatString                  string
+	outputRowsString                    string
 	reduceTestSuitesByString            string
 	reduceTestCasesByString             string
 	operationTestSuitesSkippedString    string
@@ -28,6 +41,20 @@ var (
 	operationTestSuitesTimeString       string
 	operationTestCasesTimeString        string
 	roundingModeString                  string
+	sinceString                         string
+	untilString                         string
+	lastNRuns                           int
+	stateFile                           string
+	flakyReportPath                     string
+	flakyReportFormatString             string
+	flakyProperty                       bool
+	quarantineFilePath                  string
+	quarantineFormatString              string
+	quarantineFailureRate               float64
+	quarantineFlakyRate                 float64
+	quarantineMinRuns                   int
+	timingsFilePath                     string
+	timingsFormatString                 string
 )
 
 func invalidSelectionMessage(field string, selection string, options []string) string {
@@ -49,10 +76,13 @@ func joinOptionsString(options []string) string {
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "junit-reducer",
-	Short: "Aggregates and optimizes JUnit reports for CI",
-	Long:  `JUnit Reducer streamlines CI testing by averaging JUnit reports for balanced test runner distribution.`,
+	Use:               "junit-reducer",
+	Short:             "Aggregates and optimizes JUnit reports for CI",
+	Long:              `JUnit Reducer streamlines CI testing by averaging JUnit reports for balanced test runner distribution.`,
+	PersistentPreRunE: prepareInput,
 	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
 		reduceTestSuitesBy, ok := enums.TestSuiteFieldValues[reduceTestSuitesByString]
 		if !ok {
 			fmt.Println(invalidSelectionMessage("reduce-test-suites-by", reduceTestSuitesByString, enums.GetTestSuiteFields()))
@@ -113,11 +143,69 @@ var rootCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		err := reducer.Reduce(
+		flakyReportFormat, ok := enums.ReportFormatValues[flakyReportFormatString]
+		if !ok {
+			fmt.Println(invalidSelectionMessage("flaky-report-format", flakyReportFormatString, enums.GetReportFormats()))
+			os.Exit(1)
+		}
+
+		outputFormat, ok := enums.OutputFormatValues[outputFormatString]
+		if !ok {
+			fmt.Println(invalidSelectionMessage("output-format", outputFormatString, enums.GetOutputFormats()))
+			os.Exit(1)
+		}
+
+		outputRows, ok := enums.GranularityValues[outputRowsString]
+		if !ok {
+			fmt.Println(invalidSelectionMessage("output-rows", outputRowsString, enums.GetGranularities()))
+			os.Exit(1)
+		}
+
+		quarantineFormat, ok := enums.QuarantineFormatValues[quarantineFormatString]
+		if !ok {
+			fmt.Println(invalidSelectionMessage("quarantine-format", quarantineFormatString, enums.GetQuarantineFormats()))
+			os.Exit(1)
+		}
+
+		timingsFormat, ok := enums.TimingsFormatValues[timingsFormatString]
+		if !ok {
+			fmt.Println(invalidSelectionMessage("timings-format", timingsFormatString, enums.GetTimingsFormats()))
+			os.Exit(1)
+		}
+
+		var since, until time.Time
+		if sinceString != "" {
+			since, err = reducer.ParseTimeBound(sinceString, time.Now())
+			if err != nil {
+				fmt.Printf("Invalid value for since. %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if untilString != "" {
+			until, err = reducer.ParseTimeBound(untilString, time.Now())
+			if err != nil {
+				fmt.Printf("Invalid value for until. %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		input := reportInput(cmd)
+
+		// Keep stdout clean for the reduced report when it's being piped.
+		if outputPath == reducer.StdoutPath {
+			helpers.SetMessageWriter(os.Stderr)
+		}
+
+		err = reducer.Reduce(
 			reducer.ReduceFunctionParams{
-				IncludeFilePattern:            include,
-				ExcludeFilePattern:            exclude,
+				IncludeFilePatterns:           input.IncludeFilePatterns,
+				ExcludeFilePatterns:           input.ExcludeFilePatterns,
+				FilesFrom:                     input.FilesFrom,
+				ReadReportsFromStdin:          input.ReadReportsFromStdin,
+				InputFormat:                   input.InputFormat,
 				OutputPath:                    outputPath,
+				OutputFormat:                  outputFormat,
+				OutputRows:                    outputRows,
 				ReduceTestSuitesBy:            reduceTestSuitesBy,
 				ReduceTestCasesBy:             reduceTestCasesBy,
 				OperationTestSuitesTests:      operationTestSuitesTests,
@@ -128,30 +216,104 @@ var rootCmd = &cobra.Command{
 				OperationTestSuitesTime:       operationTestSuitesTime,
 				OperationTestCasesTime:        operationTestCasesTime,
 				RoundingMode:                  roundingMode,
+				Since:                         since,
+				Until:                         until,
+				LastNRuns:                     lastNRuns,
+				StateFile:                     stateFile,
+				FlakyReportPath:               flakyReportPath,
+				FlakyReportFormat:             flakyReportFormat,
+				FlakyProperty:                 flakyProperty,
+				QuarantineFilePath:            quarantineFilePath,
+				QuarantineFormat:              quarantineFormat,
+				QuarantineThresholds: quarantine.Thresholds{
+					FailureRate: quarantineFailureRate,
+					FlakyRate:   quarantineFlakyRate,
+					MinRuns:     quarantineMinRuns,
+				},
+				TimingsFilePath: timingsFilePath,
+				TimingsFormat:   timingsFormat,
 			},
 		)
 
 		if err != nil {
-			fmt.Println(err)
+			helpers.FatalMsg("%v", err)
 			os.Exit(1)
 		}
 	},
 }
 
+// reportInput gathers the report flags shared by every command.
+func reportInput(cmd *cobra.Command) reducer.ReportInput {
+	includePatterns := include
+	// The default include pattern shouldn't pull in files when the
+	// reports are being streamed through stdin.
+	if readReportsFromStdin && !cmd.Flags().Changed("include") {
+		includePatterns = nil
+	}
+	return reducer.ReportInput{
+		IncludeFilePatterns:  includePatterns,
+		ExcludeFilePatterns:  exclude,
+		FilesFrom:            filesFrom,
+		ReadReportsFromStdin: readReportsFromStdin,
+		InputFormat:          inputFormat,
+	}
+}
+
+// prepareInput loads the config, then checks the report flags shared by every
+// command.
+func prepareInput(cmd *cobra.Command, args []string) error {
+	err := loadConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	format, ok := enums.InputFormatValues[inputFormatString]
+	if !ok {
+		return errors.New(invalidSelectionMessage("input-format", inputFormatString, enums.GetInputFormats()))
+	}
+	inputFormat = format
+	return nil
+}
+
+// exitCodeError makes the process exit with a specific code, for commands
+// that tell their failure modes apart.
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (exitErr *exitCodeError) Error() string {
+	return exitErr.err.Error()
+}
+
+func (exitErr *exitCodeError) Unwrap() error {
+	return exitErr.err
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		os.Exit(1)
 	}
 }
 
 //nolint:errcheck // Ignore errors from MarkFlagRequired
 func init() {
-	rootCmd.Flags().StringVar(&include, "include", "./**/*.xml", "Glob pattern to find JUnit XML reports to reduce")
-	rootCmd.Flags().StringVar(&outputPath, "output-path", "./output/", "Output path for the reduced JUnit XML reports")
-	rootCmd.Flags().StringVar(&exclude, "exclude", "", "Glob pattern to omit from included JUnit XML reports")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", fmt.Sprintf("Config file of flag values, overridden by %s* environment variables and flags (default: first of %s found)", config.EnvPrefix, strings.Join(config.FileNames, ", ")))
+	rootCmd.PersistentFlags().StringArrayVar(&include, "include", []string{"./**/*.xml"}, "Glob patterns to find JUnit XML reports, repeated or comma separated. Prefix with \"!\" to drop earlier matches, or use \"-\" to read report paths from stdin")
+	rootCmd.Flags().StringVar(&outputPath, "output-path", "./output/", "Output path for the reduced JUnit XML reports, or \"-\" to write a single combined report to stdout")
+	rootCmd.Flags().StringVar(&outputFormatString, "output-format", enums.OutputFormatKeys[enums.OutputFormatXML], fmt.Sprintf("Format of the reduced reports. JSON reports can be read back by including them. Options: %s", joinOptionsString(enums.GetOutputFormats())))
+	rootCmd.Flags().StringVar(&outputRowsString, "output-rows", enums.GranularityKeys[enums.GranularitySuite], fmt.Sprintf("Write a row per suite or per case when the output format is csv or tsv. Options: %s", joinOptionsString(enums.GetGranularities())))
+	rootCmd.PersistentFlags().StringArrayVar(&exclude, "exclude", nil, "Glob patterns to omit from included JUnit XML reports, repeated or comma separated")
+	rootCmd.PersistentFlags().StringVar(&filesFrom, "files-from", "", "File listing JUnit XML report paths (newline or NUL separated), or \"-\" for stdin")
+	rootCmd.PersistentFlags().BoolVar(&readReportsFromStdin, "stdin", false, "Read a concatenated stream of JUnit XML reports from stdin")
+	rootCmd.PersistentFlags().StringVar(&inputFormatString, "input-format", enums.InputFormatKeys[enums.InputFormatAuto], fmt.Sprintf("Format of the included reports, or auto to detect the format of each report from its content. Options: %s", joinOptionsString(enums.GetInputFormats())))
 	rootCmd.Flags().StringVar(&reduceTestSuitesByString, "reduce-suites-by", enums.TestSuiteFieldKeys[enums.TestSuiteFieldNameFilepath], fmt.Sprintf("Key to group and reduce test suites by. Options: %s", joinOptionsString(enums.GetTestSuiteFields())))
 	rootCmd.Flags().StringVar(&reduceTestCasesByString, "reduce-cases-by", enums.TestCaseFieldKeys[enums.TestCaseFieldName], fmt.Sprintf("Key to group and reduce test cases by. Options: %s", joinOptionsString(enums.GetTestCaseFields())))
 	rootCmd.Flags().StringVar(&operationTestSuitesSkippedString, "op-suites-skipped", enums.AggregateOperationKeys[enums.AggregateOperationMean], fmt.Sprintf("Reducer operation for test suite skipped counts. Options: %s", joinOptionsString(enums.GetAggregateOperations())))
@@ -162,4 +324,18 @@ func init() {
 	rootCmd.Flags().StringVar(&operationTestSuitesTimeString, "op-suites-time", enums.AggregateOperationKeys[enums.AggregateOperationMean], fmt.Sprintf("Reducer operation for test suite time values. Options: %s", joinOptionsString(enums.GetAggregateOperations())))
 	rootCmd.Flags().StringVar(&operationTestCasesTimeString, "op-cases-time", enums.AggregateOperationKeys[enums.AggregateOperationMean], fmt.Sprintf("Reducer operation for test case time values. Options: %s", joinOptionsString(enums.GetAggregateOperations())))
 	rootCmd.Flags().StringVar(&roundingModeString, "rounding-mode", enums.RoundingModeKeys[enums.RoundingModeRound], fmt.Sprintf("Rounding mode for counts that should be integers in the final result. Options: %s", joinOptionsString(enums.GetRoundingModes())))
+	rootCmd.Flags().StringVar(&sinceString, "since", "", "Only reduce test suites that ran after this time, from the suite timestamp or report modification time. Absolute (2024-01-31T08:00:00Z) or relative (7d, 12h)")
+	rootCmd.Flags().StringVar(&untilString, "until", "", "Only reduce test suites that ran before this time. Absolute (2024-01-31T08:00:00Z) or relative (7d, 12h)")
+	rootCmd.Flags().IntVar(&lastNRuns, "last-n-runs", 0, "Only reduce the N most recent runs of each test suite group (0 keeps all runs)")
+	rootCmd.Flags().StringVar(&flakyReportPath, "flaky-report", "", "Path to write a report of the cases that both passed and failed across the reduced runs")
+	rootCmd.Flags().StringVar(&flakyReportFormatString, "flaky-report-format", enums.ReportFormatKeys[enums.ReportFormatJSON], fmt.Sprintf("Format of the flaky test report. Options: %s", joinOptionsString(enums.GetReportFormats())))
+	rootCmd.Flags().BoolVar(&flakyProperty, "flaky-property", false, fmt.Sprintf("Add the failure rate of flaky cases to the reduced cases as a \"%s\" property", flaky.RatePropertyName))
+	rootCmd.Flags().StringVar(&quarantineFilePath, "quarantine-file", "", "Path to write the cases to quarantine, those failing or flaky beyond the quarantine rates")
+	rootCmd.Flags().StringVar(&quarantineFormatString, "quarantine-format", enums.QuarantineFormatKeys[enums.QuarantineFormatPlain], fmt.Sprintf("Format of the quarantine file. Options: %s", joinOptionsString(enums.GetQuarantineFormats())))
+	rootCmd.Flags().Float64Var(&quarantineFailureRate, "quarantine-failure-rate", 1, "Quarantine cases whose share of failed runs reaches this rate (0 disables)")
+	rootCmd.Flags().Float64Var(&quarantineFlakyRate, "quarantine-flaky-rate", 0, "Quarantine flaky cases whose share of failed runs reaches this rate (0 disables)")
+	rootCmd.Flags().IntVar(&quarantineMinRuns, "quarantine-min-runs", 3, "Minimum number of passing or failing runs before a case can be quarantined")
+	rootCmd.Flags().StringVar(&timingsFilePath, "timings-file", "", "Path to write the reduced timings in a test runner's own format, such as pytest-split's .test_durations")
+	rootCmd.Flags().StringVar(&timingsFormatString, "timings-format", enums.TimingsFormatKeys[enums.TimingsFormatPytest], fmt.Sprintf("Format of the timings file. Options: %s", joinOptionsString(enums.GetTimingsFormats())))
+	rootCmd.Flags().StringVar(&stateFile, "state-file", "", "State file to fold new reports into, so each run only reads reports that aren't in the state yet. Created if it doesn't exist")
 }
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+	"github.com/willgeorgetaylor/junit-reducer/internal/reducer"
+	"github.com/willgeorgetaylor/junit-reducer/internal/splitter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Used for flags.
+	shardTotal        int
+	shardIndex        int
+	testFilePatterns  []string
+	splitByString     string
+	fallbackTime      float64
+	splitFormatString string
+)
+
+// splitCmd balances reduced test timings across a number of shards
+var splitCmd = &cobra.Command{
+	Use:   "split",
+	Short: "Plans balanced test shards from reduced JUnit reports",
+	Long:  `Split reads reduced JUnit reports and balances their test files or cases across a number of shards, so every CI runner can pick its share from one small artifact.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		splitBy, ok := enums.GranularityValues[splitByString]
+		if !ok {
+			return errors.New(invalidSelectionMessage("split-by", splitByString, enums.GetGranularities()))
+		}
+
+		format, ok := enums.ReportFormatValues[splitFormatString]
+		if !ok {
+			return errors.New(invalidSelectionMessage("format", splitFormatString, enums.GetReportFormats()))
+		}
+
+		if shardTotal < 1 {
+			return fmt.Errorf("shard-total must be at least 1, got %d", shardTotal)
+		}
+
+		// Every shard is printed unless a shard index is given.
+		printShard := cmd.Flags().Changed("shard-index")
+		if printShard && (shardIndex < 0 || shardIndex >= shardTotal) {
+			return fmt.Errorf("shard-index must be from 0 to less than shard-total (%d), got %d", shardTotal, shardIndex)
+		}
+
+		// Keep stdout clean for the shard plan.
+		helpers.SetMessageWriter(os.Stderr)
+
+		testSuites, err := reducer.LoadReports(reportInput(cmd))
+		if err != nil {
+			return err
+		}
+
+		items := splitter.Items(testSuites, splitBy)
+
+		if len(testFilePatterns) > 0 {
+			testFiles, err := splitter.FindTestFiles(reducer.SplitPatterns(testFilePatterns))
+			if err != nil {
+				helpers.FatalMsg("failed to enumerate test files: %v", err)
+				return err
+			}
+			items = splitter.Reconcile(items, testFiles)
+		}
+
+		plan := splitter.Split(items, shardTotal, fallbackTime)
+
+		if printShard {
+			return splitter.WriteShard(os.Stdout, plan.Shards[shardIndex], format)
+		}
+		return splitter.Write(os.Stdout, plan, format)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(splitCmd)
+
+	splitCmd.Flags().IntVar(&shardTotal, "shard-total", 1, "Number of shards to split the tests across")
+	splitCmd.Flags().IntVar(&shardIndex, "shard-index", 0, "Zero-based index of the shard to print, for the runner with this index (prints every shard when not given)")
+	splitCmd.Flags().StringArrayVar(&testFilePatterns, "test-files", nil, "Glob patterns of the test files currently on disk. New files are added with the fallback time and deleted files are dropped")
+	splitCmd.Flags().StringVar(&splitByString, "split-by", enums.GranularityKeys[enums.GranularitySuite], fmt.Sprintf("Split by test file (suite) or individual test case. Options: %s", joinOptionsString(enums.GetGranularities())))
+	splitCmd.Flags().Float64Var(&fallbackTime, "fallback-time", 0, "Time in seconds assumed for tests without a recorded time (0 uses the mean of the timed tests)")
+	splitCmd.Flags().StringVar(&splitFormatString, "format", enums.ReportFormatKeys[enums.ReportFormatText], fmt.Sprintf("Output format of the shard plan. Options: %s", joinOptionsString(enums.GetReportFormats())))
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+	"github.com/willgeorgetaylor/junit-reducer/internal/reducer"
+	"github.com/willgeorgetaylor/junit-reducer/internal/stats"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Used for flags.
+	statsSuitesByString string
+	statsCasesByString  string
+	statsTop            int
+	statsFormatString   string
+)
+
+// statsCmd summarizes a set of reports without writing any output files
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Summarizes the timings of a set of JUnit reports",
+	Long:  `Stats prints totals, the slowest suites and cases, time distributions, per-file counts and the number of samples per group for the included reports, without writing any output files.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		suitesBy, ok := enums.TestSuiteFieldValues[statsSuitesByString]
+		if !ok {
+			return errors.New(invalidSelectionMessage("reduce-suites-by", statsSuitesByString, enums.GetTestSuiteFields()))
+		}
+
+		casesBy, ok := enums.TestCaseFieldValues[statsCasesByString]
+		if !ok {
+			return errors.New(invalidSelectionMessage("reduce-cases-by", statsCasesByString, enums.GetTestCaseFields()))
+		}
+
+		format, ok := enums.ReportFormatValues[statsFormatString]
+		if !ok {
+			return errors.New(invalidSelectionMessage("format", statsFormatString, enums.GetReportFormats()))
+		}
+
+		// Keep stdout clean for the summary.
+		helpers.SetMessageWriter(os.Stderr)
+
+		testSuites, err := reducer.LoadReports(reportInput(cmd))
+		if err != nil {
+			return err
+		}
+
+		report := stats.Summarize(testSuites, suitesBy, casesBy, statsTop)
+		return stats.Write(os.Stdout, report, format)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().StringVar(&statsSuitesByString, "reduce-suites-by", enums.TestSuiteFieldKeys[enums.TestSuiteFieldNameFilepath], fmt.Sprintf("Key to group test suites by. Options: %s", joinOptionsString(enums.GetTestSuiteFields())))
+	statsCmd.Flags().StringVar(&statsCasesByString, "reduce-cases-by", enums.TestCaseFieldKeys[enums.TestCaseFieldName], fmt.Sprintf("Key to group test cases by. Options: %s", joinOptionsString(enums.GetTestCaseFields())))
+	statsCmd.Flags().IntVar(&statsTop, "top", 10, "Number of slowest suites and cases to list (-1 lists all)")
+	statsCmd.Flags().StringVar(&statsFormatString, "format", enums.ReportFormatKeys[enums.ReportFormatText], fmt.Sprintf("Output format of the summary. Options: %s", joinOptionsString(enums.GetReportFormats())))
+}
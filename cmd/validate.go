@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+	"github.com/willgeorgetaylor/junit-reducer/internal/reducer"
+	"github.com/willgeorgetaylor/junit-reducer/internal/validator"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	validateExitInvalid = 1
+	validateExitUsage   = 2
+)
+
+var (
+	// Used for flags.
+	schemaString         string
+	validateFormatString string
+	validateStrict       bool
+)
+
+// validateCmd checks reports against a JUnit schema before they are uploaded
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Checks JUnit reports against a JUnit XML schema",
+	Long: `Validate checks the included reports for missing required attributes, negative or non-numeric times and counts, counts that don't match the cases they contain, and duplicate cases.
+
+Exits with 0 when the reports are valid, 1 when they are not, and 2 when the reports can't be read or the flags are invalid.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := prepareInput(cmd, args); err != nil {
+			return &exitCodeError{validateExitUsage, err}
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schema, ok := enums.JUnitSchemaValues[schemaString]
+		if !ok {
+			return &exitCodeError{validateExitUsage, errors.New(invalidSelectionMessage("schema", schemaString, enums.GetJUnitSchemas()))}
+		}
+
+		format, ok := enums.ReportFormatValues[validateFormatString]
+		if !ok {
+			return &exitCodeError{validateExitUsage, errors.New(invalidSelectionMessage("format", validateFormatString, enums.GetReportFormats()))}
+		}
+
+		if readReportsFromStdin {
+			return &exitCodeError{validateExitUsage, errors.New("validate reads report files, use --include - or --files-from - to pass their paths on stdin")}
+		}
+
+		// Keep stdout clean for the validation report.
+		helpers.SetMessageWriter(os.Stderr)
+
+		paths, err := reducer.FindReportPaths(reportInput(cmd))
+		if err != nil {
+			return &exitCodeError{validateExitUsage, err}
+		}
+		if len(paths) == 0 {
+			return &exitCodeError{validateExitUsage, errors.New("no files matched the provided include patterns")}
+		}
+
+		report, err := validator.Validate(paths, schema, validateStrict)
+		if err != nil {
+			helpers.FatalMsg("failed to read JUnit XML report: %v", err)
+			return &exitCodeError{validateExitUsage, err}
+		}
+
+		if err := validator.Write(os.Stdout, report, format); err != nil {
+			return &exitCodeError{validateExitUsage, err}
+		}
+
+		if !report.Valid {
+			return &exitCodeError{validateExitInvalid, fmt.Errorf("%d of the reports failed validation", report.InvalidFiles)}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+
+	validateCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &exitCodeError{validateExitUsage, err}
+	})
+
+	validateCmd.Flags().StringVar(&schemaString, "schema", enums.JUnitSchemaKeys[enums.JUnitSchemaAnt], fmt.Sprintf("JUnit XML variant to validate against. Options: %s", joinOptionsString(enums.GetJUnitSchemas())))
+	validateCmd.Flags().StringVar(&validateFormatString, "format", enums.ReportFormatKeys[enums.ReportFormatText], fmt.Sprintf("Output format of the validation report. Options: %s", joinOptionsString(enums.GetReportFormats())))
+	validateCmd.Flags().BoolVar(&validateStrict, "strict", false, "Fail on warnings, such as duplicate cases, as well as errors")
+}
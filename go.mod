@@ -2,10 +2,12 @@ module github.com/willgeorgetaylor/junit-reducer
 
 go 1.21.5
 
-require github.com/spf13/cobra v1.8.0
-
 require (
-	github.com/bmatcuk/doublestar v1.3.4 // indirect
-	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
+	github.com/BurntSushi/toml v1.3.2
+	github.com/bmatcuk/doublestar v1.3.4
+	github.com/spf13/cobra v1.8.0
+	github.com/spf13/pflag v1.0.5
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require github.com/inconshreveable/mousetrap v1.1.0 // indirect
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is prepended to upper-cased flag names to find environment
+// variable overrides, e.g. JUNIT_REDUCER_OP_SUITES_TIME.
+const EnvPrefix = "JUNIT_REDUCER_"
+
+// FileNames are the config files discovered in the working directory, in
+// order of preference.
+var FileNames = []string{
+	".junit-reducer.yaml",
+	".junit-reducer.yml",
+	".junit-reducer.toml",
+	".junit-reducer.json",
+}
+
+// Values holds the top-level flag values of a config file. Tables named
+// after a subcommand hold values that only apply to that subcommand.
+type Values map[string]interface{}
+
+// Discover returns the first config file found in dir, or an empty string.
+func Discover(dir string) string {
+	for _, fileName := range FileNames {
+		path := filepath.Join(dir, fileName)
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// Load parses a YAML, TOML or JSON config file, chosen by its extension.
+func Load(path string) (Values, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := Values{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	case ".json":
+		err = json.Unmarshal(data, &values)
+	default:
+		return nil, fmt.Errorf("unsupported config file format '%s', expected .yaml, .toml or .json", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+	return values, nil
+}
+
+// Section returns the values for a subcommand layered over the top-level
+// values.
+func (values Values) Section(name string) Values {
+	merged := Values{}
+	for key, value := range values {
+		if _, isTable := asTable(value); !isTable {
+			merged[normalizeKey(key)] = value
+		}
+	}
+	if section, ok := asTable(values[name]); ok {
+		for key, value := range section {
+			merged[normalizeKey(key)] = value
+		}
+	}
+	return merged
+}
+
+// Apply sets every flag that wasn't given on the command line, first from
+// its environment variable and otherwise from the config values, so the
+// precedence is flags, then environment, then config file, then defaults.
+func Apply(flags *pflag.FlagSet, values Values, lookupEnv func(string) (string, bool)) error {
+	var applyErr error
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if applyErr != nil || flag.Changed {
+			return
+		}
+
+		if envValue, ok := lookupEnv(EnvName(flag.Name)); ok {
+			if err := flags.Set(flag.Name, envValue); err != nil {
+				applyErr = fmt.Errorf("invalid value for %s: %v", EnvName(flag.Name), err)
+			}
+			return
+		}
+
+		value, ok := values[flag.Name]
+		if !ok {
+			return
+		}
+		for _, setting := range flagSettings(value) {
+			if err := flags.Set(flag.Name, setting); err != nil {
+				applyErr = fmt.Errorf("invalid value for %s in config file: %v", flag.Name, err)
+				return
+			}
+		}
+	})
+	return applyErr
+}
+
+// UnknownKeys lists config keys that don't match any of the known flag or
+// subcommand names.
+func (values Values) UnknownKeys(known map[string]bool) []string {
+	var unknown []string
+	for key, value := range values {
+		if section, isTable := asTable(value); isTable && known[key] {
+			for sectionKey := range section {
+				if !known[normalizeKey(sectionKey)] {
+					unknown = append(unknown, key+"."+sectionKey)
+				}
+			}
+			continue
+		}
+		if !known[normalizeKey(key)] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// asTable unwraps a nested table, which the YAML decoder produces as Values
+// and the TOML and JSON decoders as plain maps.
+func asTable(value interface{}) (map[string]interface{}, bool) {
+	switch table := value.(type) {
+	case Values:
+		return table, true
+	case map[string]interface{}:
+		return table, true
+	}
+	return nil, false
+}
+
+// EnvName is the environment variable that overrides a flag.
+func EnvName(flagName string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// Config keys may use underscores in place of the dashes in flag names.
+func normalizeKey(key string) string {
+	return strings.ReplaceAll(key, "_", "-")
+}
+
+func flagSettings(value interface{}) []string {
+	if list, ok := value.([]interface{}); ok {
+		settings := make([]string, 0, len(list))
+		for _, item := range list {
+			settings = append(settings, fmt.Sprint(item))
+		}
+		return settings
+	}
+	return []string{fmt.Sprint(value)}
+}
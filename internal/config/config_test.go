@@ -0,0 +1,142 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func newFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringArray("include", []string{"./**/*.xml"}, "")
+	flags.String("op-suites-time", "mean", "")
+	flags.String("rounding-mode", "round", "")
+	flags.Int("last-n-runs", 0, "")
+	return flags
+}
+
+func noEnv(string) (string, bool) {
+	return "", false
+}
+
+func TestLoadFormats(t *testing.T) {
+	for _, path := range []string{"fixtures/config.yaml", "fixtures/config.toml", "fixtures/config.json"} {
+		values, err := Load(path)
+		if err != nil {
+			t.Fatalf("expected no error loading %s, got %s", path, err)
+		}
+
+		flags := newFlagSet()
+		err = Apply(flags, values.Section("junit-reducer"), noEnv)
+		if err != nil {
+			t.Errorf("expected no error applying %s, got %s", path, err)
+		}
+
+		include, _ := flags.GetStringArray("include")
+		if !reflect.DeepEqual(include, []string{"unit/**/*.xml", "e2e/**/*.xml"}) {
+			t.Errorf("expected include patterns from %s, got %v", path, include)
+		}
+
+		operation, _ := flags.GetString("op-suites-time")
+		if operation != "median" {
+			t.Errorf("expected op-suites-time 'median' from %s, got '%s'", path, operation)
+		}
+
+		lastNRuns, _ := flags.GetInt("last-n-runs")
+		if lastNRuns != 20 {
+			t.Errorf("expected last-n-runs 20 from %s, got %d", path, lastNRuns)
+		}
+	}
+}
+
+func TestSectionOverridesTopLevel(t *testing.T) {
+	values, err := Load("fixtures/config.yaml")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	flags := newFlagSet()
+	err = Apply(flags, values.Section("split"), noEnv)
+	if err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+
+	operation, _ := flags.GetString("op-suites-time")
+	if operation != "max" {
+		t.Errorf("expected op-suites-time 'max' from split section, got '%s'", operation)
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	values := Values{"op-suites-time": "median", "rounding-mode": "ceil", "last-n-runs": 5}
+
+	env := map[string]string{
+		"JUNIT_REDUCER_OP_SUITES_TIME": "max",
+		"JUNIT_REDUCER_LAST_N_RUNS":    "10",
+	}
+	lookupEnv := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	flags := newFlagSet()
+	err := flags.Parse([]string{"--last-n-runs=15"})
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	err = Apply(flags, values, lookupEnv)
+	if err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+
+	lastNRuns, _ := flags.GetInt("last-n-runs")
+	if lastNRuns != 15 {
+		t.Errorf("expected flag to win with last-n-runs 15, got %d", lastNRuns)
+	}
+
+	operation, _ := flags.GetString("op-suites-time")
+	if operation != "max" {
+		t.Errorf("expected environment to win with op-suites-time 'max', got '%s'", operation)
+	}
+
+	roundingMode, _ := flags.GetString("rounding-mode")
+	if roundingMode != "ceil" {
+		t.Errorf("expected config file to win with rounding-mode 'ceil', got '%s'", roundingMode)
+	}
+}
+
+func TestInvalidValue(t *testing.T) {
+	flags := newFlagSet()
+	err := Apply(flags, Values{"last-n-runs": "many"}, noEnv)
+
+	if err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+func TestUnknownKeys(t *testing.T) {
+	values := Values{
+		"include":     "*.xml",
+		"op_sutes":    "max",
+		"split":       map[string]interface{}{"shard-total": 4, "shards": 4},
+		"not-a-table": map[string]interface{}{"include": "*.xml"},
+	}
+	known := map[string]bool{"include": true, "split": true, "shard-total": true}
+
+	unknown := values.UnknownKeys(known)
+	expectedUnknown := []string{"not-a-table", "op_sutes", "split.shards"}
+
+	if !reflect.DeepEqual(unknown, expectedUnknown) {
+		t.Errorf("expected unknown keys %v, got %v", expectedUnknown, unknown)
+	}
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	_, err := Load("config_test.go")
+
+	if err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
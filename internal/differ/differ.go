@@ -0,0 +1,315 @@
+package differ
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+	"github.com/willgeorgetaylor/junit-reducer/internal/reducer"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+const (
+	StatusAdded     = "added"
+	StatusRemoved   = "removed"
+	StatusChanged   = "changed"
+	StatusUnchanged = "unchanged"
+)
+
+// Change compares the time of a suite or case between the base and head
+// report sets. DeltaPercent is zero when the base time is zero.
+type Change struct {
+	Key          string  `json:"key"`
+	Name         string  `json:"name"`
+	Status       string  `json:"status"`
+	BaseTime     float64 `json:"base_time"`
+	HeadTime     float64 `json:"head_time"`
+	Delta        float64 `json:"delta"`
+	DeltaPercent float64 `json:"delta_percent"`
+}
+
+type SuiteChange struct {
+	Change
+	Regressed bool     `json:"regressed"`
+	Cases     []Change `json:"cases"`
+}
+
+type Summary struct {
+	Added        int     `json:"added"`
+	Removed      int     `json:"removed"`
+	Changed      int     `json:"changed"`
+	Regressed    int     `json:"regressed"`
+	BaseTime     float64 `json:"base_time"`
+	HeadTime     float64 `json:"head_time"`
+	CasesAdded   int     `json:"cases_added"`
+	CasesRemoved int     `json:"cases_removed"`
+}
+
+type Report struct {
+	Summary Summary       `json:"summary"`
+	Suites  []SuiteChange `json:"suites"`
+}
+
+// Thresholds decide when a slower suite counts as a regression. A zero
+// threshold is disabled, and when both are set a suite must exceed both.
+type Thresholds struct {
+	MaxSlowdownPercent float64
+	MaxSlowdownSeconds float64
+}
+
+func (thresholds Thresholds) enabled() bool {
+	return thresholds.MaxSlowdownPercent > 0 || thresholds.MaxSlowdownSeconds > 0
+}
+
+func (thresholds Thresholds) exceededBy(change Change) bool {
+	if !thresholds.enabled() || change.Status != StatusChanged || change.Delta <= 0 {
+		return false
+	}
+	if thresholds.MaxSlowdownPercent > 0 && change.DeltaPercent <= thresholds.MaxSlowdownPercent {
+		return false
+	}
+	if thresholds.MaxSlowdownSeconds > 0 && change.Delta <= thresholds.MaxSlowdownSeconds {
+		return false
+	}
+	return true
+}
+
+type timedGroup struct {
+	name  string
+	times []float64
+}
+
+type suiteGroup struct {
+	timedGroup
+	cases map[string]*timedGroup
+}
+
+// groupSuites groups suites and cases by the reducer keys. Report sets that
+// weren't reduced may hold several runs per key, which are averaged.
+func groupSuites(testSuites []serialization.TestSuite, suitesBy enums.TestSuiteField, casesBy enums.TestCaseField) map[string]*suiteGroup {
+	groups := make(map[string]*suiteGroup)
+	for _, testSuite := range testSuites {
+		key := reducer.SuiteKey(testSuite, suitesBy)
+		group, ok := groups[key]
+		if !ok {
+			group = &suiteGroup{timedGroup: timedGroup{name: testSuite.Name}, cases: make(map[string]*timedGroup)}
+			groups[key] = group
+		}
+		group.times = append(group.times, testSuite.Time)
+
+		for _, testCase := range testSuite.TestCases {
+			caseKey := reducer.CaseKey(testCase, casesBy)
+			caseGroup, ok := group.cases[caseKey]
+			if !ok {
+				caseGroup = &timedGroup{name: testCase.Name}
+				group.cases[caseKey] = caseGroup
+			}
+			caseGroup.times = append(caseGroup.times, testCase.Time)
+		}
+	}
+	return groups
+}
+
+func meanTime(times []float64) float64 {
+	var total float64 = 0
+	for _, time := range times {
+		total += time
+	}
+	return total / float64(len(times))
+}
+
+func compareGroups(key string, base *timedGroup, head *timedGroup) Change {
+	change := Change{Key: key}
+	if base != nil {
+		change.Name = base.name
+		change.BaseTime = meanTime(base.times)
+	}
+	if head != nil {
+		change.Name = head.name
+		change.HeadTime = meanTime(head.times)
+	}
+
+	change.Delta = roundDelta(change.HeadTime - change.BaseTime)
+	if change.BaseTime > 0 {
+		change.DeltaPercent = change.Delta / change.BaseTime * 100
+	}
+
+	if base == nil {
+		change.Status = StatusAdded
+	} else if head == nil {
+		change.Status = StatusRemoved
+	} else if change.Delta != 0 {
+		change.Status = StatusChanged
+	} else {
+		change.Status = StatusUnchanged
+	}
+	return change
+}
+
+func unionKeys(baseKeys []string, headKeys []string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, key := range append(baseKeys, headKeys...) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func suiteKeys(groups map[string]*suiteGroup) []string {
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func caseKeys(groups map[string]*timedGroup) []string {
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Compare matches the base and head suites and cases by the same keys used
+// to reduce them, ordering suites by how much slower they got.
+func Compare(base []serialization.TestSuite, head []serialization.TestSuite, suitesBy enums.TestSuiteField, casesBy enums.TestCaseField, thresholds Thresholds) Report {
+	baseGroups := groupSuites(base, suitesBy, casesBy)
+	headGroups := groupSuites(head, suitesBy, casesBy)
+
+	report := Report{Suites: []SuiteChange{}}
+	for _, key := range unionKeys(suiteKeys(baseGroups), suiteKeys(headGroups)) {
+		baseGroup, inBase := baseGroups[key]
+		headGroup, inHead := headGroups[key]
+
+		var baseTimes, headTimes *timedGroup
+		baseCases := map[string]*timedGroup{}
+		headCases := map[string]*timedGroup{}
+		if inBase {
+			baseTimes = &baseGroup.timedGroup
+			baseCases = baseGroup.cases
+		}
+		if inHead {
+			headTimes = &headGroup.timedGroup
+			headCases = headGroup.cases
+		}
+
+		suiteChange := SuiteChange{Change: compareGroups(key, baseTimes, headTimes), Cases: []Change{}}
+		for _, caseKey := range unionKeys(caseKeys(baseCases), caseKeys(headCases)) {
+			caseChange := compareGroups(caseKey, baseCases[caseKey], headCases[caseKey])
+			suiteChange.Cases = append(suiteChange.Cases, caseChange)
+
+			if caseChange.Status == StatusAdded {
+				report.Summary.CasesAdded++
+			} else if caseChange.Status == StatusRemoved {
+				report.Summary.CasesRemoved++
+			}
+		}
+		suiteChange.Regressed = thresholds.exceededBy(suiteChange.Change)
+
+		report.Summary.BaseTime += suiteChange.BaseTime
+		report.Summary.HeadTime += suiteChange.HeadTime
+		switch suiteChange.Status {
+		case StatusAdded:
+			report.Summary.Added++
+		case StatusRemoved:
+			report.Summary.Removed++
+		case StatusChanged:
+			report.Summary.Changed++
+		}
+		if suiteChange.Regressed {
+			report.Summary.Regressed++
+		}
+
+		report.Suites = append(report.Suites, suiteChange)
+	}
+
+	sort.SliceStable(report.Suites, func(i, j int) bool {
+		return report.Suites[i].Delta > report.Suites[j].Delta
+	})
+
+	return report
+}
+
+func formatDelta(change Change) string {
+	switch change.Status {
+	case StatusAdded:
+		return fmt.Sprintf("added (%.2fs)", change.HeadTime)
+	case StatusRemoved:
+		return fmt.Sprintf("removed (%.2fs)", change.BaseTime)
+	}
+	delta := fmt.Sprintf("%.2fs -> %.2fs, %+.2fs", change.BaseTime, change.HeadTime, change.Delta)
+	if change.BaseTime > 0 {
+		delta += fmt.Sprintf(" (%+.1f%%)", change.DeltaPercent)
+	}
+	return delta
+}
+
+var statusMarkers = map[string]string{
+	StatusAdded:     "+",
+	StatusRemoved:   "-",
+	StatusChanged:   "~",
+	StatusUnchanged: "=",
+}
+
+func Write(writer io.Writer, report Report, format enums.ReportFormat) error {
+	if format == enums.ReportFormatJSON {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	out := &helpers.ErrWriter{Writer: writer}
+	summary := report.Summary
+	totalDelta := summary.HeadTime - summary.BaseTime
+	if format == enums.ReportFormatMarkdown {
+		fmt.Fprintf(out, "## Test time diff\n\n")
+		fmt.Fprintf(out, "Total: %.2fs -> %.2fs (%+.2fs). Suites: %d changed, %d added, %d removed, %d regressed. Cases: %d added, %d removed.\n\n",
+			summary.BaseTime, summary.HeadTime, totalDelta, summary.Changed, summary.Added, summary.Removed, summary.Regressed, summary.CasesAdded, summary.CasesRemoved)
+		fmt.Fprintf(out, "| | Suite | Change |\n|---|---|---|\n")
+		for _, suite := range report.Suites {
+			if suite.Status == StatusUnchanged {
+				continue
+			}
+			marker := statusMarkers[suite.Status]
+			if suite.Regressed {
+				marker = "!"
+			}
+			fmt.Fprintf(out, "| %s | `%s` | %s |\n", marker, suite.Key, formatDelta(suite.Change))
+		}
+		return out.Err
+	}
+
+	fmt.Fprintf(out, "total: %.2fs -> %.2fs (%+.2fs)\n", summary.BaseTime, summary.HeadTime, totalDelta)
+	fmt.Fprintf(out, "suites: %d changed, %d added, %d removed, %d regressed\n", summary.Changed, summary.Added, summary.Removed, summary.Regressed)
+	fmt.Fprintf(out, "cases: %d added, %d removed\n", summary.CasesAdded, summary.CasesRemoved)
+	for _, suite := range report.Suites {
+		if suite.Status == StatusUnchanged {
+			continue
+		}
+		marker := statusMarkers[suite.Status]
+		if suite.Regressed {
+			marker = "!"
+		}
+		fmt.Fprintf(out, "%s %s: %s\n", marker, suite.Key, formatDelta(suite.Change))
+		for _, testCase := range suite.Cases {
+			if testCase.Status == StatusAdded || testCase.Status == StatusRemoved {
+				fmt.Fprintf(out, "  %s %s: %s\n", statusMarkers[testCase.Status], testCase.Key, formatDelta(testCase))
+			}
+		}
+	}
+	return out.Err
+}
+
+// roundDelta keeps floating point noise out of otherwise identical times.
+func roundDelta(delta float64) float64 {
+	return math.Round(delta*1e9) / 1e9
+}
@@ -0,0 +1,153 @@
+package differ
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+var base = []serialization.TestSuite{
+	{
+		Name: "UserTest",
+		File: "test/user_test.rb",
+		Time: 10,
+		TestCases: []serialization.TestCase{
+			{Name: "test_create", Time: 6},
+			{Name: "test_delete", Time: 4},
+		},
+	},
+	{Name: "AccountTest", File: "test/account_test.rb", Time: 5},
+	{Name: "LegacyTest", File: "test/legacy_test.rb", Time: 2},
+}
+
+var head = []serialization.TestSuite{
+	{
+		Name: "UserTest",
+		File: "test/user_test.rb",
+		Time: 13,
+		TestCases: []serialization.TestCase{
+			{Name: "test_create", Time: 9},
+			{Name: "test_update", Time: 4},
+		},
+	},
+	{Name: "AccountTest", File: "test/account_test.rb", Time: 5.5},
+	{Name: "BillingTest", File: "test/billing_test.rb", Time: 1},
+}
+
+func TestCompare(t *testing.T) {
+	report := Compare(base, head, enums.TestSuiteFieldFilepath, enums.TestCaseFieldName, Thresholds{})
+
+	expectedSummary := Summary{
+		Added:        1,
+		Removed:      1,
+		Changed:      2,
+		BaseTime:     17,
+		HeadTime:     19.5,
+		CasesAdded:   1,
+		CasesRemoved: 1,
+	}
+
+	if report.Summary != expectedSummary {
+		t.Errorf("expected summary %+v, got %+v", expectedSummary, report.Summary)
+	}
+
+	expectedOrder := []string{"test/user_test.rb", "test/billing_test.rb", "test/account_test.rb", "test/legacy_test.rb"}
+	for i, key := range expectedOrder {
+		if report.Suites[i].Key != key {
+			t.Errorf("expected suite %d to be '%s', got '%s'", i, key, report.Suites[i].Key)
+		}
+	}
+
+	userTest := report.Suites[0]
+	if userTest.Delta != 3 || userTest.DeltaPercent != 30 {
+		t.Errorf("expected a delta of +3s (+30%%), got %+fs (%+f%%)", userTest.Delta, userTest.DeltaPercent)
+	}
+
+	expectedCaseStatuses := map[string]string{
+		"test_create": StatusChanged,
+		"test_delete": StatusRemoved,
+		"test_update": StatusAdded,
+	}
+	for _, testCase := range userTest.Cases {
+		if testCase.Status != expectedCaseStatuses[testCase.Key] {
+			t.Errorf("expected case '%s' to be %s, got %s", testCase.Key, expectedCaseStatuses[testCase.Key], testCase.Status)
+		}
+	}
+}
+
+func TestCompareAveragesUnreducedRuns(t *testing.T) {
+	head := []serialization.TestSuite{
+		{Name: "AccountTest", Time: 4},
+		{Name: "AccountTest", Time: 8},
+	}
+
+	report := Compare(base, head, enums.TestSuiteFieldName, enums.TestCaseFieldName, Thresholds{})
+
+	for _, suite := range report.Suites {
+		if suite.Key == "AccountTest" && suite.HeadTime != 6 {
+			t.Errorf("expected head time to be the mean of 6s, got %fs", suite.HeadTime)
+		}
+	}
+}
+
+func TestThresholds(t *testing.T) {
+	thresholdCases := []struct {
+		thresholds        Thresholds
+		expectedRegressed int
+	}{
+		{Thresholds{}, 0},
+		{Thresholds{MaxSlowdownPercent: 5}, 2},
+		{Thresholds{MaxSlowdownPercent: 20}, 1},
+		{Thresholds{MaxSlowdownSeconds: 1}, 1},
+		{Thresholds{MaxSlowdownPercent: 5, MaxSlowdownSeconds: 5}, 0},
+	}
+
+	for _, thresholdCase := range thresholdCases {
+		report := Compare(base, head, enums.TestSuiteFieldFilepath, enums.TestCaseFieldName, thresholdCase.thresholds)
+		if report.Summary.Regressed != thresholdCase.expectedRegressed {
+			t.Errorf("expected %d regressions with thresholds %+v, got %d", thresholdCase.expectedRegressed, thresholdCase.thresholds, report.Summary.Regressed)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	report := Compare(base, head, enums.TestSuiteFieldFilepath, enums.TestCaseFieldName, Thresholds{MaxSlowdownPercent: 20})
+
+	expectedOutputs := map[enums.ReportFormat]string{
+		enums.ReportFormatText:     "! test/user_test.rb: 10.00s -> 13.00s, +3.00s (+30.0%)\n  - test_delete: removed (4.00s)\n  + test_update: added (4.00s)\n",
+		enums.ReportFormatMarkdown: "| - | `test/legacy_test.rb` | removed (2.00s) |",
+		enums.ReportFormatJSON:     `"regressed": 1`,
+	}
+
+	for format, expectedOutput := range expectedOutputs {
+		var output bytes.Buffer
+		err := Write(&output, report, format)
+		if err != nil {
+			t.Errorf("expected no error, got %s", err)
+		}
+		if !strings.Contains(output.String(), expectedOutput) {
+			t.Errorf("expected %s output to contain %q, got %q", enums.ReportFormatKeys[format], expectedOutput, output.String())
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriteReturnsWriteErrors(t *testing.T) {
+	report := Compare(base, head, enums.TestSuiteFieldFilepath, enums.TestCaseFieldName, Thresholds{})
+
+	for _, format := range []enums.ReportFormat{enums.ReportFormatText, enums.ReportFormatMarkdown, enums.ReportFormatJSON} {
+		err := Write(failingWriter{}, report, format)
+		if err == nil || err.Error() != "disk full" {
+			t.Errorf("expected %s output to return the write error, got %v", enums.ReportFormatKeys[format], err)
+		}
+	}
+}
@@ -144,3 +144,300 @@ func GetRoundingModes() []string {
 	helpers.SortStrings(RoundingModeInputs)
 	return RoundingModeInputs
 }
+
+// Granularity
+
+type Granularity int
+
+const (
+	GranularitySuite Granularity = iota
+	GranularityCase
+)
+
+var GranularityKeys = map[Granularity]string{
+	GranularitySuite: "suite",
+	GranularityCase:  "case",
+}
+
+var GranularityValues = map[string]Granularity{
+	"suite": GranularitySuite,
+	"case":  GranularityCase,
+}
+
+func GetGranularities() []string {
+	GranularityInputs := make([]string, len(GranularityValues))
+	i := 0
+	for key := range GranularityValues {
+		GranularityInputs[i] = key
+		i++
+	}
+	helpers.SortStrings(GranularityInputs)
+	return GranularityInputs
+}
+
+// Report formats
+
+type ReportFormat int
+
+const (
+	ReportFormatText ReportFormat = iota
+	ReportFormatJSON
+	ReportFormatMarkdown
+)
+
+var ReportFormatKeys = map[ReportFormat]string{
+	ReportFormatText:     "text",
+	ReportFormatJSON:     "json",
+	ReportFormatMarkdown: "markdown",
+}
+
+var ReportFormatValues = map[string]ReportFormat{
+	"text":     ReportFormatText,
+	"json":     ReportFormatJSON,
+	"markdown": ReportFormatMarkdown,
+}
+
+func GetReportFormats() []string {
+	ReportFormatInputs := make([]string, len(ReportFormatValues))
+	i := 0
+	for key := range ReportFormatValues {
+		ReportFormatInputs[i] = key
+		i++
+	}
+	helpers.SortStrings(ReportFormatInputs)
+	return ReportFormatInputs
+}
+
+// JUnit schemas
+
+type JUnitSchema int
+
+const (
+	JUnitSchemaAnt JUnitSchema = iota
+	JUnitSchemaJenkins
+	JUnitSchemaGitLab
+)
+
+var JUnitSchemaKeys = map[JUnitSchema]string{
+	JUnitSchemaAnt:     "ant",
+	JUnitSchemaJenkins: "jenkins",
+	JUnitSchemaGitLab:  "gitlab",
+}
+
+var JUnitSchemaValues = map[string]JUnitSchema{
+	"ant":     JUnitSchemaAnt,
+	"jenkins": JUnitSchemaJenkins,
+	"gitlab":  JUnitSchemaGitLab,
+}
+
+func GetJUnitSchemas() []string {
+	JUnitSchemaInputs := make([]string, len(JUnitSchemaValues))
+	i := 0
+	for key := range JUnitSchemaValues {
+		JUnitSchemaInputs[i] = key
+		i++
+	}
+	helpers.SortStrings(JUnitSchemaInputs)
+	return JUnitSchemaInputs
+}
+
+// Conflict resolutions
+
+type ConflictResolution int
+
+const (
+	ConflictResolutionFirst ConflictResolution = iota
+	ConflictResolutionLast
+	ConflictResolutionLongest
+	ConflictResolutionError
+)
+
+var ConflictResolutionKeys = map[ConflictResolution]string{
+	ConflictResolutionFirst:   "first",
+	ConflictResolutionLast:    "last",
+	ConflictResolutionLongest: "longest",
+	ConflictResolutionError:   "error",
+}
+
+var ConflictResolutionValues = map[string]ConflictResolution{
+	"first":   ConflictResolutionFirst,
+	"last":    ConflictResolutionLast,
+	"longest": ConflictResolutionLongest,
+	"error":   ConflictResolutionError,
+}
+
+func GetConflictResolutions() []string {
+	ConflictResolutionInputs := make([]string, len(ConflictResolutionValues))
+	i := 0
+	for key := range ConflictResolutionValues {
+		ConflictResolutionInputs[i] = key
+		i++
+	}
+	helpers.SortStrings(ConflictResolutionInputs)
+	return ConflictResolutionInputs
+}
+
+// Quarantine formats
+
+type QuarantineFormat int
+
+const (
+	QuarantineFormatPlain QuarantineFormat = iota
+	QuarantineFormatJSON
+	QuarantineFormatPytest
+	QuarantineFormatGoTest
+)
+
+var QuarantineFormatKeys = map[QuarantineFormat]string{
+	QuarantineFormatPlain:  "plain",
+	QuarantineFormatJSON:   "json",
+	QuarantineFormatPytest: "pytest",
+	QuarantineFormatGoTest: "gotest",
+}
+
+var QuarantineFormatValues = map[string]QuarantineFormat{
+	"plain":  QuarantineFormatPlain,
+	"json":   QuarantineFormatJSON,
+	"pytest": QuarantineFormatPytest,
+	"gotest": QuarantineFormatGoTest,
+}
+
+func GetQuarantineFormats() []string {
+	QuarantineFormatInputs := make([]string, len(QuarantineFormatValues))
+	i := 0
+	for key := range QuarantineFormatValues {
+		QuarantineFormatInputs[i] = key
+		i++
+	}
+	helpers.SortStrings(QuarantineFormatInputs)
+	return QuarantineFormatInputs
+}
+
+// Timings formats
+
+type TimingsFormat int
+
+const (
+	TimingsFormatPytest TimingsFormat = iota
+	TimingsFormatParallelTests
+	TimingsFormatKnapsack
+	TimingsFormatJest
+	TimingsFormatPlaywright
+)
+
+var TimingsFormatKeys = map[TimingsFormat]string{
+	TimingsFormatPytest:        "pytest",
+	TimingsFormatParallelTests: "parallel-tests",
+	TimingsFormatKnapsack:      "knapsack",
+	TimingsFormatJest:          "jest",
+	TimingsFormatPlaywright:    "playwright",
+}
+
+var TimingsFormatValues = map[string]TimingsFormat{
+	"pytest":         TimingsFormatPytest,
+	"parallel-tests": TimingsFormatParallelTests,
+	"knapsack":       TimingsFormatKnapsack,
+	"jest":           TimingsFormatJest,
+	"playwright":     TimingsFormatPlaywright,
+}
+
+func GetTimingsFormats() []string {
+	TimingsFormatInputs := make([]string, len(TimingsFormatValues))
+	i := 0
+	for key := range TimingsFormatValues {
+		TimingsFormatInputs[i] = key
+		i++
+	}
+	helpers.SortStrings(TimingsFormatInputs)
+	return TimingsFormatInputs
+}
+
+// Input formats
+
+type InputFormat int
+
+const (
+	InputFormatAuto InputFormat = iota
+	InputFormatJUnit
+	InputFormatJSON
+	InputFormatGoTest
+	InputFormatTRX
+	InputFormatNUnit
+	InputFormatXUnit
+	InputFormatCucumber
+	InputFormatCTRF
+)
+
+var InputFormatKeys = map[InputFormat]string{
+	InputFormatAuto:     "auto",
+	InputFormatJUnit:    "junit",
+	InputFormatJSON:     "json",
+	InputFormatGoTest:   "gotest",
+	InputFormatTRX:      "trx",
+	InputFormatNUnit:    "nunit",
+	InputFormatXUnit:    "xunit",
+	InputFormatCucumber: "cucumber",
+	InputFormatCTRF:     "ctrf",
+}
+
+var InputFormatValues = map[string]InputFormat{
+	"auto":     InputFormatAuto,
+	"junit":    InputFormatJUnit,
+	"json":     InputFormatJSON,
+	"gotest":   InputFormatGoTest,
+	"trx":      InputFormatTRX,
+	"nunit":    InputFormatNUnit,
+	"xunit":    InputFormatXUnit,
+	"cucumber": InputFormatCucumber,
+	"ctrf":     InputFormatCTRF,
+}
+
+func GetInputFormats() []string {
+	InputFormatInputs := make([]string, len(InputFormatValues))
+	i := 0
+	for key := range InputFormatValues {
+		InputFormatInputs[i] = key
+		i++
+	}
+	helpers.SortStrings(InputFormatInputs)
+	return InputFormatInputs
+}
+
+// Output formats
+
+type OutputFormat int
+
+const (
+	OutputFormatXML OutputFormat = iota
+	OutputFormatJSON
+	OutputFormatCSV
+	OutputFormatTSV
+	OutputFormatCTRF
+)
+
+var OutputFormatKeys = map[OutputFormat]string{
+	OutputFormatXML:  "xml",
+	OutputFormatJSON: "json",
+	OutputFormatCSV:  "csv",
+	OutputFormatTSV:  "tsv",
+	OutputFormatCTRF: "ctrf",
+}
+
+var OutputFormatValues = map[string]OutputFormat{
+	"xml":  OutputFormatXML,
+	"json": OutputFormatJSON,
+	"csv":  OutputFormatCSV,
+	"tsv":  OutputFormatTSV,
+	"ctrf": OutputFormatCTRF,
+}
+
+func GetOutputFormats() []string {
+	OutputFormatInputs := make([]string, len(OutputFormatValues))
+	i := 0
+	for key := range OutputFormatValues {
+		OutputFormatInputs[i] = key
+		i++
+	}
+	helpers.SortStrings(OutputFormatInputs)
+	return OutputFormatInputs
+}
@@ -66,3 +66,83 @@ func TestGetRoundingModes(t *testing.T) {
 		t.Errorf("Expected modes %v, but got %v", expectedModes, actualModes)
 	}
 }
+
+func TestGetGranularities(t *testing.T) {
+	expectedGranularities := []string{"case", "suite"}
+
+	actualGranularities := GetGranularities()
+
+	if !reflect.DeepEqual(actualGranularities, expectedGranularities) {
+		t.Errorf("Expected granularities %v, but got %v", expectedGranularities, actualGranularities)
+	}
+}
+
+func TestGetReportFormats(t *testing.T) {
+	expectedFormats := []string{"json", "markdown", "text"}
+
+	actualFormats := GetReportFormats()
+
+	if !reflect.DeepEqual(actualFormats, expectedFormats) {
+		t.Errorf("Expected formats %v, but got %v", expectedFormats, actualFormats)
+	}
+}
+
+func TestGetJUnitSchemas(t *testing.T) {
+	expectedSchemas := []string{"ant", "gitlab", "jenkins"}
+
+	actualSchemas := GetJUnitSchemas()
+
+	if !reflect.DeepEqual(actualSchemas, expectedSchemas) {
+		t.Errorf("Expected schemas %v, but got %v", expectedSchemas, actualSchemas)
+	}
+}
+
+func TestGetConflictResolutions(t *testing.T) {
+	expectedResolutions := []string{"error", "first", "last", "longest"}
+
+	actualResolutions := GetConflictResolutions()
+
+	if !reflect.DeepEqual(actualResolutions, expectedResolutions) {
+		t.Errorf("Expected resolutions %v, but got %v", expectedResolutions, actualResolutions)
+	}
+}
+
+func TestGetQuarantineFormats(t *testing.T) {
+	expectedFormats := []string{"gotest", "json", "plain", "pytest"}
+
+	actualFormats := GetQuarantineFormats()
+
+	if !reflect.DeepEqual(actualFormats, expectedFormats) {
+		t.Errorf("Expected formats %v, but got %v", expectedFormats, actualFormats)
+	}
+}
+
+func TestGetOutputFormats(t *testing.T) {
+	expectedFormats := []string{"csv", "ctrf", "json", "tsv", "xml"}
+
+	actualFormats := GetOutputFormats()
+
+	if !reflect.DeepEqual(actualFormats, expectedFormats) {
+		t.Errorf("Expected formats %v, but got %v", expectedFormats, actualFormats)
+	}
+}
+
+func TestGetTimingsFormats(t *testing.T) {
+	expectedFormats := []string{"jest", "knapsack", "parallel-tests", "playwright", "pytest"}
+
+	actualFormats := GetTimingsFormats()
+
+	if !reflect.DeepEqual(actualFormats, expectedFormats) {
+		t.Errorf("Expected formats %v, but got %v", expectedFormats, actualFormats)
+	}
+}
+
+func TestGetInputFormats(t *testing.T) {
+	expectedFormats := []string{"auto", "ctrf", "cucumber", "gotest", "json", "junit", "nunit", "trx", "xunit"}
+
+	actualFormats := GetInputFormats()
+
+	if !reflect.DeepEqual(actualFormats, expectedFormats) {
+		t.Errorf("Expected formats %v, but got %v", expectedFormats, actualFormats)
+	}
+}
@@ -0,0 +1,139 @@
+package flaky
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+// RatePropertyName is the property added to reduced cases that are flaky.
+const RatePropertyName = "flaky-rate"
+
+type Case struct {
+	Suite     string  `json:"suite"`
+	File      string  `json:"file,omitempty"`
+	Name      string  `json:"name"`
+	Classname string  `json:"classname,omitempty"`
+	Runs      int     `json:"runs"`
+	Passed    int     `json:"passed"`
+	Failed    int     `json:"failed"`
+	Errored   int     `json:"errored"`
+	Skipped   int     `json:"skipped"`
+	Rate      float64 `json:"rate"`
+}
+
+type Summary struct {
+	Cases int `json:"cases"`
+	Flaky int `json:"flaky"`
+}
+
+type Report struct {
+	Summary Summary `json:"summary"`
+	Cases   []Case  `json:"cases"`
+}
+
+// Rate is the share of the runs that passed or failed which failed. Skipped
+// runs don't count either way.
+func Rate(outcomes serialization.Outcomes) float64 {
+	failed := outcomes.Failed + outcomes.Errored
+	if failed+outcomes.Passed == 0 {
+		return 0
+	}
+	return float64(failed) / float64(failed+outcomes.Passed)
+}
+
+// IsFlaky reports whether a case both passed and failed across its runs.
+func IsFlaky(outcomes serialization.Outcomes) bool {
+	return outcomes.Passed > 0 && outcomes.Failed+outcomes.Errored > 0
+}
+
+// Detect lists the flaky cases among reduced suites, whose cases carry the
+// outcomes of the runs they were reduced from, most flaky first.
+func Detect(testSuites []serialization.TestSuite) Report {
+	report := Report{Cases: []Case{}}
+	for _, testSuite := range testSuites {
+		for _, testCase := range testSuite.TestCases {
+			report.Summary.Cases++
+			outcomes := testCase.Outcomes
+			if !IsFlaky(outcomes) {
+				continue
+			}
+			report.Cases = append(report.Cases, Case{
+				Suite:     testSuite.Name,
+				File:      testSuite.File,
+				Name:      testCase.Name,
+				Classname: testCase.Classname,
+				Runs:      outcomes.Passed + outcomes.Failed + outcomes.Errored + outcomes.Skipped,
+				Passed:    outcomes.Passed,
+				Failed:    outcomes.Failed,
+				Errored:   outcomes.Errored,
+				Skipped:   outcomes.Skipped,
+				Rate:      Rate(outcomes),
+			})
+		}
+	}
+	report.Summary.Flaky = len(report.Cases)
+
+	sort.SliceStable(report.Cases, func(i, j int) bool {
+		if report.Cases[i].Rate != report.Cases[j].Rate {
+			return report.Cases[i].Rate > report.Cases[j].Rate
+		}
+		if report.Cases[i].Suite != report.Cases[j].Suite {
+			return report.Cases[i].Suite < report.Cases[j].Suite
+		}
+		return report.Cases[i].Name < report.Cases[j].Name
+	})
+
+	return report
+}
+
+// Annotate adds the flakiness rate of every flaky reduced case as a
+// property.
+func Annotate(testSuites []serialization.TestSuite) {
+	for i := range testSuites {
+		for j := range testSuites[i].TestCases {
+			testCase := &testSuites[i].TestCases[j]
+			if !IsFlaky(testCase.Outcomes) {
+				continue
+			}
+			testCase.Properties = append(testCase.Properties, serialization.Property{
+				Name:  RatePropertyName,
+				Value: strconv.FormatFloat(Rate(testCase.Outcomes), 'f', 4, 64),
+			})
+		}
+	}
+}
+
+func Write(writer io.Writer, report Report, format enums.ReportFormat) error {
+	if format == enums.ReportFormatJSON {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	if format == enums.ReportFormatMarkdown {
+		fmt.Fprintf(writer, "## Flaky tests\n\n")
+		fmt.Fprintf(writer, "%d of %d cases both passed and failed.\n", report.Summary.Flaky, report.Summary.Cases)
+		if len(report.Cases) == 0 {
+			return nil
+		}
+		fmt.Fprintf(writer, "\n| Suite | Case | Failure rate | Passed | Failed | Errored |\n|---|---|---|---|---|---|\n")
+		for _, flakyCase := range report.Cases {
+			fmt.Fprintf(writer, "| `%s` | `%s` | %.1f%% | %d | %d | %d |\n", flakyCase.Suite, flakyCase.Name, flakyCase.Rate*100, flakyCase.Passed, flakyCase.Failed, flakyCase.Errored)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(writer, "flaky: %d of %d cases\n", report.Summary.Flaky, report.Summary.Cases)
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	for _, flakyCase := range report.Cases {
+		fmt.Fprintf(table, "%.1f%%\t%d/%d failed\t%s\t%s\n", flakyCase.Rate*100, flakyCase.Failed+flakyCase.Errored, flakyCase.Passed+flakyCase.Failed+flakyCase.Errored, flakyCase.Suite, flakyCase.Name)
+	}
+	return table.Flush()
+}
@@ -0,0 +1,104 @@
+package flaky
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+var reducedSuites = []serialization.TestSuite{
+	{
+		Name: "UserTest",
+		File: "test/user_test.rb",
+		TestCases: []serialization.TestCase{
+			{Name: "test_create", Outcomes: serialization.Outcomes{Passed: 4}},
+			{Name: "test_update", Outcomes: serialization.Outcomes{Passed: 3, Failed: 1}},
+			{Name: "test_destroy", Outcomes: serialization.Outcomes{Failed: 4}},
+		},
+	},
+	{
+		Name: "AccountTest",
+		File: "test/account_test.rb",
+		TestCases: []serialization.TestCase{
+			{Name: "test_show", Outcomes: serialization.Outcomes{Passed: 1, Errored: 1, Skipped: 2}},
+		},
+	},
+}
+
+func TestRate(t *testing.T) {
+	if rate := Rate(serialization.Outcomes{Passed: 1, Failed: 1, Errored: 2, Skipped: 5}); rate != 0.75 {
+		t.Errorf("Expected rate 0.75, but got %v", rate)
+	}
+	if rate := Rate(serialization.Outcomes{Skipped: 3}); rate != 0 {
+		t.Errorf("Expected rate 0 for skipped runs, but got %v", rate)
+	}
+}
+
+func TestCaseOutcomes(t *testing.T) {
+	tests := []struct {
+		testCase serialization.TestCase
+		expected serialization.Outcomes
+	}{
+		{serialization.TestCase{}, serialization.Outcomes{Passed: 1}},
+		{serialization.TestCase{Failure: &serialization.Result{}}, serialization.Outcomes{Failed: 1}},
+		{serialization.TestCase{Failure: &serialization.Result{}, Error: &serialization.Result{}}, serialization.Outcomes{Errored: 1}},
+		{serialization.TestCase{Skipped: &serialization.Result{}}, serialization.Outcomes{Skipped: 1}},
+		{serialization.TestCase{Samples: 3}, serialization.Outcomes{}},
+	}
+
+	for _, test := range tests {
+		if actual := serialization.CaseOutcomes(test.testCase); actual != test.expected {
+			t.Errorf("Expected outcomes %+v for %+v, but got %+v", test.expected, test.testCase, actual)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	report := Detect(reducedSuites)
+
+	if report.Summary.Cases != 4 || report.Summary.Flaky != 2 {
+		t.Errorf("Expected 2 of 4 cases to be flaky, but got %+v", report.Summary)
+	}
+	if report.Cases[0].Name != "test_show" || report.Cases[0].Rate != 0.5 || report.Cases[0].Runs != 4 {
+		t.Errorf("Expected the most flaky case first, but got %+v", report.Cases[0])
+	}
+	if report.Cases[1].Name != "test_update" || report.Cases[1].Rate != 0.25 {
+		t.Errorf("Expected test_update second, but got %+v", report.Cases[1])
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	suites := make([]serialization.TestSuite, len(reducedSuites))
+	for i, testSuite := range reducedSuites {
+		suites[i] = testSuite
+		suites[i].TestCases = append([]serialization.TestCase{}, testSuite.TestCases...)
+	}
+
+	Annotate(suites)
+
+	if len(suites[0].TestCases[0].Properties) != 0 || len(suites[0].TestCases[2].Properties) != 0 {
+		t.Errorf("Expected stable cases not to be annotated")
+	}
+	properties := suites[0].TestCases[1].Properties
+	if len(properties) != 1 || properties[0].Name != RatePropertyName || properties[0].Value != "0.2500" {
+		t.Errorf("Expected a flaky rate property, but got %v", properties)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := Write(&buffer, Detect(reducedSuites), enums.ReportFormatMarkdown); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "## Flaky tests\n\n" +
+		"2 of 4 cases both passed and failed.\n\n" +
+		"| Suite | Case | Failure rate | Passed | Failed | Errored |\n|---|---|---|---|---|---|\n" +
+		"| `AccountTest` | `test_show` | 50.0% | 1 | 0 | 1 |\n" +
+		"| `UserTest` | `test_update` | 25.0% | 3 | 1 | 0 |\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected output %q, but got %q", expected, buffer.String())
+	}
+}
@@ -2,17 +2,42 @@ package helpers
 
 import (
 	"fmt"
+	"io"
 	"os"
 )
 
+var messageWriter io.Writer = os.Stdout
+
+// SetMessageWriter redirects PrintMsg output, e.g. to stderr when stdout is
+// reserved for the reduced report.
+func SetMessageWriter(writer io.Writer) {
+	messageWriter = writer
+}
+
 func PrintMsg(format string, args ...interface{}) {
-	fmt.Fprintf(os.Stdout, format+"\n", args...)
+	fmt.Fprintf(messageWriter, format+"\n", args...)
 }
 
 func FatalMsg(format string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, format+"\n", args...)
 }
 
+// ErrWriter keeps the first error of a series of writes, skipping the writes
+// that follow it, so reports can be written without checking every write.
+type ErrWriter struct {
+	Writer io.Writer
+	Err    error
+}
+
+func (w *ErrWriter) Write(p []byte) (int, error) {
+	if w.Err != nil {
+		return 0, w.Err
+	}
+	n, err := w.Writer.Write(p)
+	w.Err = err
+	return n, err
+}
+
 func FileExists(path string) bool {
 	info, err := os.Stat(path)
 	if os.IsNotExist(err) {
@@ -0,0 +1,59 @@
+package merger
+
+import (
+	"fmt"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+	"github.com/willgeorgetaylor/junit-reducer/internal/reducer"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+// Merge combines the suites of several reports into a single document
+// without reducing them. Suites sharing a key are resolved by the conflict
+// resolution, and merged suites keep the position of the first suite with
+// their key. The root totals are recomputed from the merged suites.
+func Merge(testSuites []serialization.TestSuite, suitesBy enums.TestSuiteField, resolution enums.ConflictResolution) (serialization.TestSuites, error) {
+	merged := make([]serialization.TestSuite, 0, len(testSuites))
+	indexes := make(map[string]int)
+
+	for _, testSuite := range testSuites {
+		key := reducer.SuiteKey(testSuite, suitesBy)
+		index, duplicate := indexes[key]
+		if !duplicate {
+			indexes[key] = len(merged)
+			merged = append(merged, testSuite)
+			continue
+		}
+
+		existing := merged[index]
+		switch resolution {
+		case enums.ConflictResolutionError:
+			return serialization.TestSuites{}, fmt.Errorf("test suite '%s' appears in both %s and %s", key, existing.FileName, testSuite.FileName)
+		case enums.ConflictResolutionLast:
+			merged[index] = testSuite
+		case enums.ConflictResolutionLongest:
+			if testSuite.Time > existing.Time {
+				merged[index] = testSuite
+			}
+		}
+		helpers.PrintMsg("resolved duplicate test suite %s from %s and %s\n", key, existing.FileName, testSuite.FileName)
+	}
+
+	return Totals(merged), nil
+}
+
+// Totals wraps the suites in a document whose root totals are the sums of
+// the suite totals.
+func Totals(testSuites []serialization.TestSuite) serialization.TestSuites {
+	document := serialization.TestSuites{TestSuites: testSuites}
+	for _, testSuite := range testSuites {
+		document.Time += testSuite.Time
+		document.Tests += testSuite.Tests
+		document.Failed += testSuite.Failed
+		document.Errors += testSuite.Errors
+		document.Skipped += testSuite.Skipped
+		document.Assertions += testSuite.Assertions
+	}
+	return document
+}
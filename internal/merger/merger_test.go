@@ -0,0 +1,83 @@
+package merger
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+var shards = []serialization.TestSuite{
+	{Name: "UserTest", File: "test/user_test.rb", FileName: "runner-1.xml", Time: 4, Tests: 2, Failed: 1},
+	{Name: "AccountTest", File: "test/account_test.rb", FileName: "runner-1.xml", Time: 2, Tests: 1},
+	{Name: "UserTest", File: "test/user_test.rb", FileName: "runner-2.xml", Time: 5, Tests: 2},
+	{Name: "JobTest", File: "test/job_test.rb", FileName: "runner-2.xml", Time: 3, Tests: 3, Skipped: 1},
+}
+
+func mergedTimes(document serialization.TestSuites) []float64 {
+	times := []float64{}
+	for _, testSuite := range document.TestSuites {
+		times = append(times, testSuite.Time)
+	}
+	return times
+}
+
+func TestMergeConflictResolutions(t *testing.T) {
+	tests := []struct {
+		resolution enums.ConflictResolution
+		expected   []float64
+	}{
+		{enums.ConflictResolutionFirst, []float64{4, 2, 3}},
+		{enums.ConflictResolutionLast, []float64{5, 2, 3}},
+		{enums.ConflictResolutionLongest, []float64{5, 2, 3}},
+	}
+
+	for _, test := range tests {
+		document, err := Merge(shards, enums.TestSuiteFieldNameFilepath, test.resolution)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := mergedTimes(document); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Expected times %v for %s, but got %v", test.expected, enums.ConflictResolutionKeys[test.resolution], actual)
+		}
+	}
+}
+
+func TestMergeConflictError(t *testing.T) {
+	_, err := Merge(shards, enums.TestSuiteFieldNameFilepath, enums.ConflictResolutionError)
+	if err == nil || !strings.Contains(err.Error(), "runner-1.xml and runner-2.xml") {
+		t.Errorf("Expected an error naming both reports, but got %v", err)
+	}
+
+	_, err = Merge(shards[:2], enums.TestSuiteFieldNameFilepath, enums.ConflictResolutionError)
+	if err != nil {
+		t.Errorf("Expected no error without duplicates, but got %v", err)
+	}
+}
+
+func TestMergeRecomputesTotals(t *testing.T) {
+	document, err := Merge(shards, enums.TestSuiteFieldNameFilepath, enums.ConflictResolutionFirst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if document.Time != 9 || document.Tests != 6 || document.Failed != 1 || document.Skipped != 1 {
+		t.Errorf("Expected totals of the merged suites, but got %+v", document)
+	}
+}
+
+func TestWriteMergedDocument(t *testing.T) {
+	document := Totals(shards[1:2])
+
+	var buffer bytes.Buffer
+	if err := serialization.WriteDocument(&buffer, document); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buffer.String(), `<testsuites time="2" tests="1">`) {
+		t.Errorf("Expected root totals on the testsuites element, but got %s", buffer.String())
+	}
+}
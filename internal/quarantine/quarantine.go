@@ -0,0 +1,156 @@
+package quarantine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/flaky"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+const (
+	ReasonFailing = "failing"
+	ReasonFlaky   = "flaky"
+)
+
+// Thresholds decide which cases are quarantined. A case is quarantined when
+// its failure rate reaches FailureRate, or when it's flaky and its failure
+// rate reaches FlakyRate. A zero rate disables that check. Cases with fewer
+// than MinRuns passing or failing runs are never quarantined.
+type Thresholds struct {
+	FailureRate float64
+	FlakyRate   float64
+	MinRuns     int
+}
+
+type Entry struct {
+	Suite       string  `json:"suite"`
+	File        string  `json:"file,omitempty"`
+	Classname   string  `json:"classname,omitempty"`
+	Name        string  `json:"name"`
+	Reason      string  `json:"reason"`
+	Runs        int     `json:"runs"`
+	FailureRate float64 `json:"failure_rate"`
+}
+
+// Select lists the reduced cases to quarantine, ordered by suite and name.
+func Select(testSuites []serialization.TestSuite, thresholds Thresholds) []Entry {
+	entries := []Entry{}
+	for _, testSuite := range testSuites {
+		for _, testCase := range testSuite.TestCases {
+			outcomes := testCase.Outcomes
+			runs := outcomes.Passed + outcomes.Failed + outcomes.Errored
+			if runs == 0 || runs < thresholds.MinRuns {
+				continue
+			}
+
+			rate := flaky.Rate(outcomes)
+			isFlaky := flaky.IsFlaky(outcomes)
+			failing := thresholds.FailureRate > 0 && rate >= thresholds.FailureRate
+			flakyEnough := isFlaky && thresholds.FlakyRate > 0 && rate >= thresholds.FlakyRate
+			if !failing && !flakyEnough {
+				continue
+			}
+
+			reason := ReasonFailing
+			if isFlaky {
+				reason = ReasonFlaky
+			}
+
+			file := testCase.File
+			if file == "" {
+				file = testSuite.File
+			}
+			entries = append(entries, Entry{
+				Suite:       testSuite.Name,
+				File:        file,
+				Classname:   testCase.Classname,
+				Name:        testCase.Name,
+				Reason:      reason,
+				Runs:        runs,
+				FailureRate: rate,
+			})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Suite != entries[j].Suite {
+			return entries[i].Suite < entries[j].Suite
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// goTestPatterns builds a -skip pattern for `go test` per package, from the
+// classname go reports record the package in, or every package when there's
+// none. Each case is matched level by level, so skipping a subtest leaves its
+// siblings running.
+func goTestPatterns(entries []Entry) map[string]string {
+	seen := make(map[string]bool)
+	alternatives := make(map[string][]string)
+	for _, entry := range entries {
+		levels := strings.Split(entry.Name, "/")
+		for i, level := range levels {
+			levels[i] = "^" + regexp.QuoteMeta(level) + "$"
+		}
+		alternative := strings.Join(levels, "/")
+
+		pkg := entry.Classname
+		if pkg == "" {
+			pkg = "./..."
+		}
+		key := pkg + "\x00" + alternative
+		if !seen[key] {
+			seen[key] = true
+			alternatives[pkg] = append(alternatives[pkg], alternative)
+		}
+	}
+
+	patterns := make(map[string]string)
+	for pkg, pkgAlternatives := range alternatives {
+		sort.Strings(pkgAlternatives)
+		patterns[pkg] = strings.Join(pkgAlternatives, "|")
+	}
+	return patterns
+}
+
+func Write(writer io.Writer, entries []Entry, format enums.QuarantineFormat) error {
+	switch format {
+	case enums.QuarantineFormatJSON:
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	case enums.QuarantineFormatGoTest:
+		patterns := goTestPatterns(entries)
+		packages := make([]string, 0, len(patterns))
+		for pkg := range patterns {
+			packages = append(packages, pkg)
+		}
+		sort.Strings(packages)
+		for _, pkg := range packages {
+			if _, err := fmt.Fprintln(writer, pkg, patterns[pkg]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, entry := range entries {
+		var line string
+		if format == enums.QuarantineFormatPytest {
+			line = "--deselect " + serialization.PytestNodeID(entry.File, entry.Classname, entry.Name)
+		} else {
+			line = serialization.CaseID(serialization.TestCase{File: entry.File, Classname: entry.Classname, Name: entry.Name})
+		}
+		if _, err := fmt.Fprintln(writer, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
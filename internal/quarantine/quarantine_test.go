@@ -0,0 +1,92 @@
+package quarantine
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+var reducedSuites = []serialization.TestSuite{
+	{
+		Name: "tests.test_user.TestUser",
+		File: "tests/test_user.py",
+		TestCases: []serialization.TestCase{
+			{Name: "test_create", Classname: "tests.test_user.TestUser", Outcomes: serialization.Outcomes{Passed: 4}},
+			{Name: "test_update", Classname: "tests.test_user.TestUser", Outcomes: serialization.Outcomes{Passed: 3, Failed: 1}},
+			{Name: "test_destroy", Classname: "tests.test_user.TestUser", Outcomes: serialization.Outcomes{Failed: 2, Errored: 2}},
+			{Name: "test_login", Classname: "tests.test_user.TestUser", Outcomes: serialization.Outcomes{Passed: 1, Failed: 3}},
+			{Name: "test_logout", Classname: "tests.test_user.TestUser", Outcomes: serialization.Outcomes{Failed: 1, Skipped: 5}},
+		},
+	},
+}
+
+func names(entries []Entry) []string {
+	result := []string{}
+	for _, entry := range entries {
+		result = append(result, entry.Name+":"+entry.Reason)
+	}
+	return result
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		thresholds Thresholds
+		expected   []string
+	}{
+		{Thresholds{FailureRate: 1, MinRuns: 3}, []string{"test_destroy:failing"}},
+		{Thresholds{FailureRate: 1, MinRuns: 1}, []string{"test_destroy:failing", "test_logout:failing"}},
+		{Thresholds{FailureRate: 1, FlakyRate: 0.5, MinRuns: 3}, []string{"test_destroy:failing", "test_login:flaky"}},
+		{Thresholds{FlakyRate: 0.2, MinRuns: 3}, []string{"test_login:flaky", "test_update:flaky"}},
+		{Thresholds{FailureRate: 0.7, MinRuns: 3}, []string{"test_destroy:failing", "test_login:flaky"}},
+		{Thresholds{MinRuns: 1}, []string{}},
+	}
+
+	for _, test := range tests {
+		actual := names(Select(reducedSuites, test.thresholds))
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Expected %v for %+v, but got %v", test.expected, test.thresholds, actual)
+		}
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	entries := Select(reducedSuites, Thresholds{FailureRate: 1, FlakyRate: 0.5, MinRuns: 3})
+
+	tests := map[enums.QuarantineFormat]string{
+		enums.QuarantineFormatPlain:  "tests/test_user.py::test_destroy\ntests/test_user.py::test_login\n",
+		enums.QuarantineFormatPytest: "--deselect tests/test_user.py::TestUser::test_destroy\n--deselect tests/test_user.py::TestUser::test_login\n",
+		enums.QuarantineFormatGoTest: "tests.test_user.TestUser ^test_destroy$|^test_login$\n",
+	}
+
+	for format, expected := range tests {
+		var buffer bytes.Buffer
+		if err := Write(&buffer, entries, format); err != nil {
+			t.Fatal(err)
+		}
+		if buffer.String() != expected {
+			t.Errorf("Expected %s output %q, but got %q", enums.QuarantineFormatKeys[format], expected, buffer.String())
+		}
+	}
+}
+
+func TestGoTestPatternsSkipEachCasePerPackage(t *testing.T) {
+	entries := []Entry{
+		{Classname: "example.com/api", Name: "TestUser/update"},
+		{Classname: "example.com/api", Name: "TestUser/create"},
+		{Classname: "example.com/api", Name: "TestAccount.Show"},
+		{Classname: "example.com/web", Name: "TestUser/update"},
+		{Name: "TestPing"},
+	}
+
+	expected := map[string]string{
+		"example.com/api": `^TestAccount\.Show$|^TestUser$/^create$|^TestUser$/^update$`,
+		"example.com/web": `^TestUser$/^update$`,
+		"./...":           `^TestPing$`,
+	}
+	if patterns := goTestPatterns(entries); !reflect.DeepEqual(patterns, expected) {
+		t.Errorf("Expected patterns %v, but got %v", expected, patterns)
+	}
+}
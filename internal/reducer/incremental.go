@@ -0,0 +1,248 @@
+package reducer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+	"github.com/willgeorgetaylor/junit-reducer/internal/state"
+)
+
+// reduceIncrementally folds the reports that aren't in the state file yet
+// into its accumulators, writes the reduced reports for the whole state and
+// saves the updated state, so each run only reads its new reports.
+func reduceIncrementally(params ReduceFunctionParams) error {
+	if !params.Since.IsZero() || !params.Until.IsZero() || params.LastNRuns > 0 {
+		return errors.New("a state file can't be combined with since, until or last-n-runs, as runs can't be removed from it")
+	}
+	if params.Stdin == nil {
+		params.Stdin = os.Stdin
+	}
+
+	reducerState, err := state.Load(params.StateFile, enums.TestSuiteFieldKeys[params.ReduceTestSuitesBy], enums.TestCaseFieldKeys[params.ReduceTestCasesBy])
+	if err != nil {
+		helpers.FatalMsg("failed to load state file: %v", err)
+		return err
+	}
+
+	paths, err := FindReportPaths(ReportInput{
+		IncludeFilePatterns:  params.IncludeFilePatterns,
+		ExcludeFilePatterns:  params.ExcludeFilePatterns,
+		FilesFrom:            params.FilesFrom,
+		ReadReportsFromStdin: params.ReadReportsFromStdin,
+		Stdin:                params.Stdin,
+	})
+	if err != nil {
+		return err
+	}
+
+	newPaths, digests, err := unfoldedReports(paths, reducerState)
+	if err != nil {
+		helpers.FatalMsg("failed to read JUnit XML report: %v", err)
+		return err
+	}
+
+	testSuites, err := serialization.Deserialize(newPaths, params.InputFormat)
+	if err != nil {
+		helpers.FatalMsg("failed to deserialize JUnit XML reports: %v", err)
+		return err
+	}
+
+	if params.ReadReportsFromStdin {
+		testSuites, digests, err = foldableStdin(testSuites, digests, params.Stdin, params.InputFormat, reducerState)
+		if err != nil {
+			helpers.FatalMsg("failed to deserialize JUnit XML reports from stdin: %v", err)
+			return err
+		}
+	}
+
+	if len(reducerState.Suites) == 0 && len(testSuites) == 0 {
+		return errors.New("no test suites found in the provided reports or state file")
+	}
+
+	foldSuites(reducerState, testSuites, params)
+	for _, digest := range digests {
+		reducerState.Reports[digest] = true
+	}
+
+	err = writeReducedSuites(reduceState(reducerState, params), params)
+	if err != nil {
+		return err
+	}
+
+	helpers.PrintMsg("saving state: %v\n", params.StateFile)
+	err = reducerState.Save(params.StateFile)
+	if err != nil {
+		helpers.FatalMsg("failed to save state file: %v", err)
+		return err
+	}
+	return nil
+}
+
+// unfoldedReports returns the paths of reports that haven't been folded into
+// the state yet, along with their digests.
+func unfoldedReports(paths []string, reducerState *state.State) ([]string, []string, error) {
+	var newPaths, digests []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		digest, err := state.Digest(file)
+		file.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if reducerState.Reports[digest] || seen[digest] {
+			helpers.PrintMsg("skipping report already in state: %v\n", path)
+			continue
+		}
+		seen[digest] = true
+		newPaths = append(newPaths, path)
+		digests = append(digests, digest)
+	}
+	return newPaths, digests, nil
+}
+
+func foldableStdin(testSuites []serialization.TestSuite, digests []string, stdin io.Reader, inputFormat enums.InputFormat, reducerState *state.State) ([]serialization.TestSuite, []string, error) {
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, nil, err
+	}
+	digest, err := state.Digest(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	if reducerState.Reports[digest] {
+		helpers.PrintMsg("skipping stdin reports already in state\n")
+		return testSuites, digests, nil
+	}
+
+	helpers.PrintMsg("deserializing junit xml stream from stdin")
+	testSuites, err = serialization.DeserializeStream(testSuites, bytes.NewReader(data), StdinFileName, inputFormat)
+	if err != nil {
+		return nil, nil, err
+	}
+	return testSuites, append(digests, digest), nil
+}
+
+func foldSuites(reducerState *state.State, testSuites []serialization.TestSuite, params ReduceFunctionParams) {
+	for _, testSuite := range testSuites {
+		suiteKey := SuiteKey(testSuite, params.ReduceTestSuitesBy)
+		suiteState, ok := reducerState.Suites[suiteKey]
+		if !ok {
+			suiteState = &state.Suite{
+				Name:     testSuite.Name,
+				File:     testSuite.File,
+				FileName: testSuite.FileName,
+			}
+			reducerState.Suites[suiteKey] = suiteState
+		}
+		if suiteState.Cases == nil {
+			suiteState.Cases = make(map[string]*state.Case)
+		}
+
+		weight := serialization.SuiteWeight(testSuite)
+		suiteState.Time.Add(testSuite.Time, weight)
+		suiteState.Tests.Add(float64(testSuite.Tests), weight)
+		suiteState.Failed.Add(float64(testSuite.Failed), weight)
+		suiteState.Errors.Add(float64(testSuite.Errors), weight)
+		suiteState.Skipped.Add(float64(testSuite.Skipped), weight)
+		suiteState.Assertions.Add(float64(testSuite.Assertions), weight)
+
+		for _, testCase := range testSuite.TestCases {
+			caseKey := CaseKey(testCase, params.ReduceTestCasesBy)
+			caseState, ok := suiteState.Cases[caseKey]
+			if !ok {
+				caseState = &state.Case{
+					Name:       testCase.Name,
+					Classname:  testCase.Classname,
+					File:       testCase.File,
+					Line:       testCase.Line,
+					Assertions: testCase.Assertions,
+				}
+				suiteState.Cases[caseKey] = caseState
+			}
+			caseState.Time.Add(testCase.Time, serialization.CaseWeight(testCase))
+			caseState.Outcomes = caseState.Outcomes.Add(serialization.CaseOutcomes(testCase))
+		}
+	}
+}
+
+// reduceState reduces the accumulators of every group in the state, in key
+// order.
+func reduceState(reducerState *state.State, params ReduceFunctionParams) []serialization.TestSuite {
+	suiteKeys := make([]string, 0, len(reducerState.Suites))
+	for key := range reducerState.Suites {
+		suiteKeys = append(suiteKeys, key)
+	}
+	sort.Strings(suiteKeys)
+
+	testSuites := make([]serialization.TestSuite, 0, len(suiteKeys))
+	for _, suiteKey := range suiteKeys {
+		suiteState := reducerState.Suites[suiteKey]
+		testSuite := serialization.TestSuite{
+			Name:       suiteState.Name,
+			File:       suiteState.File,
+			FileName:   suiteState.FileName,
+			Time:       reduceAccumulator(suiteState.Time, params.OperationTestSuitesTime),
+			Tests:      roundToInt(reduceAccumulator(suiteState.Tests, params.OperationTestSuitesTests), params.RoundingMode),
+			Failed:     roundToInt(reduceAccumulator(suiteState.Failed, params.OperationTestSuitesFailed), params.RoundingMode),
+			Errors:     roundToInt(reduceAccumulator(suiteState.Errors, params.OperationTestSuitesErrors), params.RoundingMode),
+			Skipped:    roundToInt(reduceAccumulator(suiteState.Skipped, params.OperationTestSuitesSkipped), params.RoundingMode),
+			Assertions: roundToInt(reduceAccumulator(suiteState.Assertions, params.OperationTestSuitesAssertions), params.RoundingMode),
+			Samples:    suiteState.Time.Count,
+			TestCases:  make([]serialization.TestCase, 0, len(suiteState.Cases)),
+		}
+
+		caseKeys := make([]string, 0, len(suiteState.Cases))
+		for key := range suiteState.Cases {
+			caseKeys = append(caseKeys, key)
+		}
+		sort.Strings(caseKeys)
+
+		for _, caseKey := range caseKeys {
+			caseState := suiteState.Cases[caseKey]
+			testSuite.TestCases = append(testSuite.TestCases, serialization.TestCase{
+				Name:       caseState.Name,
+				Classname:  caseState.Classname,
+				File:       caseState.File,
+				Line:       caseState.Line,
+				Assertions: caseState.Assertions,
+				Time:       reduceAccumulator(caseState.Time, params.OperationTestCasesTime),
+				Samples:    caseState.Time.Count,
+				Outcomes:   caseState.Outcomes,
+			})
+		}
+
+		testSuites = append(testSuites, testSuite)
+	}
+	return testSuites
+}
+
+// reduceAccumulator applies an operation to an accumulator. Medians and
+// modes are taken from its samples, so they are estimates once a group has
+// seen more values than fit in the reservoir.
+func reduceAccumulator(accumulator state.Accumulator, operation enums.AggregateOperation) float64 {
+	if accumulator.Count == 0 {
+		return 0
+	}
+	if operation == enums.AggregateOperationMax {
+		return accumulator.Max
+	} else if operation == enums.AggregateOperationMin {
+		return accumulator.Min
+	} else if operation == enums.AggregateOperationSum {
+		return accumulator.Total
+	} else if operation == enums.AggregateOperationMode || operation == enums.AggregateOperationMedian {
+		return reduce(accumulator.Samples, operation)
+	} else {
+		return accumulator.Mean()
+	}
+}
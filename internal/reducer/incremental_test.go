@@ -0,0 +1,131 @@
+package reducer
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+	"github.com/willgeorgetaylor/junit-reducer/internal/state"
+)
+
+func incrementalParams(stateFile string, include string, stdout *bytes.Buffer) ReduceFunctionParams {
+	return ReduceFunctionParams{
+		IncludeFilePatterns:           []string{include},
+		OutputPath:                    "-",
+		Stdout:                        stdout,
+		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
+		ReduceTestCasesBy:             enums.TestCaseFieldName,
+		OperationTestSuitesTests:      enums.AggregateOperationMean,
+		OperationTestSuitesFailed:     enums.AggregateOperationMean,
+		OperationTestSuitesErrors:     enums.AggregateOperationMean,
+		OperationTestSuitesSkipped:    enums.AggregateOperationMean,
+		OperationTestSuitesAssertions: enums.AggregateOperationMean,
+		OperationTestSuitesTime:       enums.AggregateOperationMean,
+		OperationTestCasesTime:        enums.AggregateOperationMean,
+		RoundingMode:                  enums.RoundingModeRound,
+		StateFile:                     stateFile,
+	}
+}
+
+func reduceToSuites(t *testing.T, params ReduceFunctionParams) []serialization.TestSuite {
+	err := Reduce(params)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	xmlTestSuites, err := serialization.UnmarshalTestSuites(params.Stdout.(*bytes.Buffer).Bytes(), "stdout")
+	if err != nil {
+		t.Fatalf("error parsing JUnit XML from stdout: %v", err)
+	}
+	return xmlTestSuites.TestSuites
+}
+
+func TestIncrementalReduceMatchesBatchReduce(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	var batchOutput bytes.Buffer
+	batch := reduceToSuites(t, incrementalParams("", "fixtures/valid/*.xml", &batchOutput))
+
+	var firstOutput, secondOutput bytes.Buffer
+	reduceToSuites(t, incrementalParams(stateFile, "fixtures/valid/Sample.xml", &firstOutput))
+	incremental := reduceToSuites(t, incrementalParams(stateFile, "fixtures/valid/Sample2.xml", &secondOutput))
+
+	if len(incremental) != 1 || len(batch) != 1 {
+		t.Fatalf("expected 1 reduced test suite, got %d and %d", len(incremental), len(batch))
+	}
+	if incremental[0].Time != batch[0].Time {
+		t.Errorf("expected incremental time %v to match batch time %v", incremental[0].Time, batch[0].Time)
+	}
+	if incremental[0].Tests != batch[0].Tests || incremental[0].Assertions != batch[0].Assertions {
+		t.Errorf("expected incremental counts %+v to match batch counts %+v", incremental[0], batch[0])
+	}
+	if len(incremental[0].TestCases) != len(batch[0].TestCases) {
+		t.Errorf("expected %d reduced test cases, got %d", len(batch[0].TestCases), len(incremental[0].TestCases))
+	}
+}
+
+func TestIncrementalReduceSkipsFoldedReports(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	var firstOutput, secondOutput bytes.Buffer
+	reduceToSuites(t, incrementalParams(stateFile, "fixtures/valid/*.xml", &firstOutput))
+	reduceToSuites(t, incrementalParams(stateFile, "fixtures/valid/*.xml", &secondOutput))
+
+	if firstOutput.String() != secondOutput.String() {
+		t.Errorf("expected folding the same reports twice to leave the output unchanged")
+	}
+
+	reducerState, err := state.Load(stateFile, "name+filepath", "name")
+	if err != nil {
+		t.Fatalf("expected no error loading state, got %s", err)
+	}
+	for key, suiteState := range reducerState.Suites {
+		if suiteState.Time.Count != 2 {
+			t.Errorf("expected suite '%s' to have 2 runs in state, got %d", key, suiteState.Time.Count)
+		}
+	}
+}
+
+func TestIncrementalReduceRejectsDifferentKeys(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	var firstOutput, secondOutput bytes.Buffer
+	reduceToSuites(t, incrementalParams(stateFile, "fixtures/valid/Sample.xml", &firstOutput))
+
+	params := incrementalParams(stateFile, "fixtures/valid/Sample2.xml", &secondOutput)
+	params.ReduceTestSuitesBy = enums.TestSuiteFieldName
+	if err := Reduce(params); err == nil {
+		t.Errorf("expected an error for a state reduced by different keys")
+	}
+}
+
+func TestIncrementalReduceRejectsTimeWindow(t *testing.T) {
+	var stdout bytes.Buffer
+	params := incrementalParams(filepath.Join(t.TempDir(), "state.json"), "fixtures/valid/*.xml", &stdout)
+	params.LastNRuns = 5
+	if err := Reduce(params); err == nil {
+		t.Errorf("expected an error combining a state file with last-n-runs")
+	}
+}
+
+func TestReduceAccumulator(t *testing.T) {
+	var accumulator state.Accumulator
+	for _, value := range []float64{4, 1, 3, 3, 9} {
+		accumulator.Add(value, 1)
+	}
+
+	tests := map[enums.AggregateOperation]float64{
+		enums.AggregateOperationMean:   4,
+		enums.AggregateOperationSum:    20,
+		enums.AggregateOperationMin:    1,
+		enums.AggregateOperationMax:    9,
+		enums.AggregateOperationMedian: 3,
+		enums.AggregateOperationMode:   3,
+	}
+	for operation, expected := range tests {
+		if actual := reduceAccumulator(accumulator, operation); actual != expected {
+			t.Errorf("expected %s of %v, got %v", enums.AggregateOperationKeys[operation], expected, actual)
+		}
+	}
+}
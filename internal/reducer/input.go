@@ -0,0 +1,251 @@
+package reducer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+// StdinPattern is the include pattern that reads report paths from stdin.
+const StdinPattern = "-"
+
+// StdinFileName is the report file name given to suites read from stdin.
+const StdinFileName = "stdin.xml"
+
+// ReportInput describes where to find the reports to load.
+type ReportInput struct {
+	IncludeFilePatterns  []string
+	ExcludeFilePatterns  []string
+	FilesFrom            string
+	ReadReportsFromStdin bool
+	Stdin                io.Reader
+	InputFormat          enums.InputFormat
+}
+
+// LoadReports finds and deserializes the test suites of every report
+// described by the input.
+func LoadReports(params ReportInput) ([]serialization.TestSuite, error) {
+	if params.Stdin == nil {
+		params.Stdin = os.Stdin
+	}
+
+	filesSlice, err := FindReportPaths(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(filesSlice) == 0 && !params.ReadReportsFromStdin {
+		return nil, errors.New("no files matched the provided include patterns")
+	}
+
+	// Deserialize
+	testSuites, err := serialization.Deserialize(filesSlice, params.InputFormat)
+
+	if err != nil {
+		helpers.FatalMsg("failed to deserialize JUnit XML reports: %v", err)
+		return nil, err
+	}
+
+	if params.ReadReportsFromStdin {
+		helpers.PrintMsg("deserializing junit xml stream from stdin")
+		testSuites, err = serialization.DeserializeStream(testSuites, params.Stdin, StdinFileName, params.InputFormat)
+		if err != nil {
+			helpers.FatalMsg("failed to deserialize JUnit XML reports from stdin: %v", err)
+			return nil, err
+		}
+	}
+
+	return testSuites, nil
+}
+
+// FindReportPaths resolves the report paths described by the input without
+// reading the reports themselves.
+func FindReportPaths(params ReportInput) ([]string, error) {
+	if params.Stdin == nil {
+		params.Stdin = os.Stdin
+	}
+
+	params.IncludeFilePatterns = SplitPatterns(params.IncludeFilePatterns)
+	params.ExcludeFilePatterns = SplitPatterns(params.ExcludeFilePatterns)
+
+	stdinUses := 0
+	for _, pattern := range params.IncludeFilePatterns {
+		if pattern == StdinPattern {
+			stdinUses++
+		}
+	}
+	if params.FilesFrom == StdinPattern {
+		stdinUses++
+	}
+	if params.ReadReportsFromStdin {
+		stdinUses++
+	}
+	if stdinUses > 1 {
+		return nil, errors.New("stdin can only be used for one of report paths or report content")
+	}
+
+	// Excluded files are already dropped, so negating an exclude pattern has
+	// no meaning. Use a negated include pattern instead.
+	for _, pattern := range params.ExcludeFilePatterns {
+		if strings.HasPrefix(pattern, "!") {
+			return nil, fmt.Errorf("exclude pattern '%s' can't be negated; add '%s' to the include patterns instead", pattern, pattern)
+		}
+	}
+
+	return collectReportPaths(params)
+}
+
+// collectReportPaths resolves the files-from list, include patterns and
+// exclude patterns into a sorted list of report paths. Include patterns are
+// applied in order, and a pattern prefixed with "!" removes the files matched
+// so far, like a negated .gitignore entry.
+func collectReportPaths(params ReportInput) ([]string, error) {
+	files := make(map[string]bool)
+
+	if params.FilesFrom != "" {
+		paths, err := readPathListFrom(params.FilesFrom, params.Stdin)
+		if err != nil {
+			helpers.FatalMsg("failed to read JUnit XML report paths from %s: %v", params.FilesFrom, err)
+			return nil, err
+		}
+		for _, file := range paths {
+			files[file] = true
+		}
+	}
+
+	for _, pattern := range params.IncludeFilePatterns {
+		if pattern == StdinPattern {
+			paths, err := readPathList(params.Stdin)
+			if err != nil {
+				helpers.FatalMsg("failed to read JUnit XML report paths from stdin: %v", err)
+				return nil, err
+			}
+			for _, file := range paths {
+				files[file] = true
+			}
+			continue
+		}
+
+		negated := strings.HasPrefix(pattern, "!")
+		matches, err := doublestar.Glob(strings.TrimPrefix(pattern, "!"))
+
+		if err != nil {
+			helpers.FatalMsg("failed to enumerate included JUnit XML reports: %v", err)
+			return nil, err
+		}
+		for _, file := range matches {
+			if negated {
+				delete(files, file)
+			} else {
+				files[file] = true
+			}
+		}
+	}
+
+	// Exclude files
+	for _, pattern := range params.ExcludeFilePatterns {
+		excludedFiles, err := doublestar.Glob(pattern)
+
+		if err != nil {
+			helpers.FatalMsg("failed to enumerate excluded JUnit XML reports: %v", err)
+			return nil, err
+		}
+		for _, file := range excludedFiles {
+			if files[file] {
+				helpers.PrintMsg("excluding file: %v", file)
+			}
+			delete(files, file)
+		}
+	}
+
+	// Get paths to included files
+	filesSlice := make([]string, 0, len(files))
+
+	for file := range files {
+		filesSlice = append(filesSlice, file)
+	}
+
+	// Order alphabetically
+	helpers.SortStrings(filesSlice)
+
+	return filesSlice, nil
+}
+
+// SplitPatterns flattens repeated pattern flags and comma separated lists
+// into a single list. Commas inside brace expansions such as "{unit,e2e}"
+// are left intact.
+func SplitPatterns(values []string) []string {
+	var patterns []string
+	for _, value := range values {
+		depth := 0
+		start := 0
+		for i, char := range value {
+			switch char {
+			case '{':
+				depth++
+			case '}':
+				if depth > 0 {
+					depth--
+				}
+			case ',':
+				if depth == 0 {
+					patterns = appendPattern(patterns, value[start:i])
+					start = i + 1
+				}
+			}
+		}
+		patterns = appendPattern(patterns, value[start:])
+	}
+	return patterns
+}
+
+func appendPattern(patterns []string, pattern string) []string {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return patterns
+	}
+	return append(patterns, pattern)
+}
+
+func readPathListFrom(path string, stdin io.Reader) ([]string, error) {
+	if path == StdinPattern {
+		return readPathList(stdin)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return readPathList(file)
+}
+
+// readPathList reads report paths separated by newlines, or by NUL bytes when
+// the list contains any (as produced by `find -print0`).
+func readPathList(reader io.Reader) ([]string, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	separator := byte('\n')
+	if bytes.IndexByte(data, 0) >= 0 {
+		separator = 0
+	}
+
+	var paths []string
+	for _, entry := range bytes.Split(data, []byte{separator}) {
+		path := strings.TrimSpace(string(entry))
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
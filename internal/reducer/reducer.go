@@ -2,20 +2,35 @@ package reducer
 
 import (
 	"errors"
+	"io"
 	"math"
 	"os"
+	"path/filepath"
 	"sort"
+	"time"
 
-	"github.com/bmatcuk/doublestar"
 	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/flaky"
 	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+	"github.com/willgeorgetaylor/junit-reducer/internal/quarantine"
 	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+	"github.com/willgeorgetaylor/junit-reducer/internal/timings"
 )
 
+// StdoutPath is the output path that writes the reduced reports to stdout.
+const StdoutPath = "-"
+
 type ReduceFunctionParams struct {
-	IncludeFilePattern            string
-	ExcludeFilePattern            string
+	IncludeFilePatterns           []string
+	ExcludeFilePatterns           []string
+	FilesFrom                     string
+	ReadReportsFromStdin          bool
+	Stdin                         io.Reader
+	InputFormat                   enums.InputFormat
 	OutputPath                    string
+	OutputFormat                  enums.OutputFormat
+	OutputRows                    enums.Granularity
+	Stdout                        io.Writer
 	ReduceTestSuitesBy            enums.TestSuiteField
 	ReduceTestCasesBy             enums.TestCaseField
 	OperationTestSuitesTests      enums.AggregateOperation
@@ -26,54 +41,43 @@ type ReduceFunctionParams struct {
 	OperationTestSuitesTime       enums.AggregateOperation
 	OperationTestCasesTime        enums.AggregateOperation
 	RoundingMode                  enums.RoundingMode
+	Since                         time.Time
+	Until                         time.Time
+	LastNRuns                     int
+	StateFile                     string
+	FlakyReportPath               string
+	FlakyReportFormat             enums.ReportFormat
+	FlakyProperty                 bool
+	QuarantineFilePath            string
+	QuarantineFormat              enums.QuarantineFormat
+	QuarantineThresholds          quarantine.Thresholds
+	TimingsFilePath               string
+	TimingsFormat                 enums.TimingsFormat
 }
 
 func Reduce(params ReduceFunctionParams) error {
-	files := make(map[string]bool)
-	includedReports, err := doublestar.Glob(params.IncludeFilePattern)
+	if params.StateFile != "" {
+		return reduceIncrementally(params)
+	}
 
+	testSuites, err := LoadReports(ReportInput{
+		IncludeFilePatterns:  params.IncludeFilePatterns,
+		ExcludeFilePatterns:  params.ExcludeFilePatterns,
+		FilesFrom:            params.FilesFrom,
+		ReadReportsFromStdin: params.ReadReportsFromStdin,
+		Stdin:                params.Stdin,
+		InputFormat:          params.InputFormat,
+	})
 	if err != nil {
-		helpers.FatalMsg("failed to enumerate included JUnit XML reports: %v", err)
 		return err
 	}
-	for _, file := range includedReports {
-		files[file] = true
-	}
 
-	// Exclude files
-	if params.ExcludeFilePattern != "" {
-		excludedFiles, err := doublestar.Glob(params.ExcludeFilePattern)
+	if !params.Since.IsZero() || !params.Until.IsZero() {
+		testSuites = filterByTimeWindow(testSuites, params.Since, params.Until)
 
-		if err != nil {
-			helpers.FatalMsg("failed to enumerate excluded JUnit XML reports: %v", err)
-			return err
+		if len(testSuites) == 0 {
+			return errors.New("no test suites found within the provided time window")
 		}
-		for _, file := range excludedFiles {
-			helpers.PrintMsg("excluding file: %v", file)
-			delete(files, file)
-		}
-	}
-
-	if (len(files)) == 0 {
-		return errors.New("no files matched the provided include pattern")
-	}
-
-	// Get paths to included files
-	filesSlice := make([]string, 0, len(files))
-
-	for file := range files {
-		filesSlice = append(filesSlice, file)
-	}
-
-	// Order alphabetically
-	helpers.SortStrings(filesSlice)
-
-	// Deserialize
-	testSuites, err := serialization.Deserialize(filesSlice)
-
-	if err != nil {
-		helpers.FatalMsg("failed to deserialize JUnit XML reports: %v", err)
-		return err
 	}
 
 	// For now, just reduce testsuites by filepath, and average time values
@@ -82,17 +86,13 @@ func Reduce(params ReduceFunctionParams) error {
 	testSuiteMap := make(map[string][]serialization.TestSuite)
 
 	for _, testSuite := range testSuites {
-		suiteKey := testSuite.Name
-		if params.ReduceTestSuitesBy == enums.TestSuiteFieldNameFilepath {
-			suiteKey = testSuite.File + ":" + testSuite.Name
-		} else if params.ReduceTestSuitesBy == enums.TestSuiteFieldFilepath {
-			suiteKey = testSuite.File
-		}
+		suiteKey := SuiteKey(testSuite, params.ReduceTestSuitesBy)
 		testSuiteMap[suiteKey] = append(testSuiteMap[suiteKey], testSuite)
 	}
 
 	// Reduce times and other aggregate fields
 	for key, testSuiteSlice := range testSuiteMap {
+		testSuiteSlice = lastNRuns(testSuiteSlice, params.LastNRuns)
 		reducedTestSlice := reduceTestSuiteSlice(testSuiteSlice, params)
 		testSuiteMap[key] = reducedTestSlice
 	}
@@ -103,18 +103,113 @@ func Reduce(params ReduceFunctionParams) error {
 		testSuites = append(testSuites, testSuiteSlice...)
 	}
 
+	return writeReducedSuites(testSuites, params)
+}
+
+func writeReducedSuites(testSuites []serialization.TestSuite, params ReduceFunctionParams) error {
+	if params.FlakyProperty {
+		flaky.Annotate(testSuites)
+	}
+
+	if params.FlakyReportPath != "" {
+		helpers.PrintMsg("writing flaky test report: %v\n", params.FlakyReportPath)
+		err := writeReportFile(params.FlakyReportPath, func(writer io.Writer) error {
+			return flaky.Write(writer, flaky.Detect(testSuites), params.FlakyReportFormat)
+		})
+		if err != nil {
+			helpers.FatalMsg("failed to write flaky test report: %v", err)
+			return err
+		}
+	}
+
+	if params.QuarantineFilePath != "" {
+		entries := quarantine.Select(testSuites, params.QuarantineThresholds)
+		helpers.PrintMsg("writing quarantine file with %d cases: %v\n", len(entries), params.QuarantineFilePath)
+		err := writeReportFile(params.QuarantineFilePath, func(writer io.Writer) error {
+			return quarantine.Write(writer, entries, params.QuarantineFormat)
+		})
+		if err != nil {
+			helpers.FatalMsg("failed to write quarantine file: %v", err)
+			return err
+		}
+	}
+
+	if params.TimingsFilePath != "" {
+		helpers.PrintMsg("writing timings file: %v\n", params.TimingsFilePath)
+		err := writeReportFile(params.TimingsFilePath, func(writer io.Writer) error {
+			return timings.Write(writer, testSuites, params.TimingsFormat)
+		})
+		if err != nil {
+			helpers.FatalMsg("failed to write timings file: %v", err)
+			return err
+		}
+	}
+
+	keyOf := func(testSuite serialization.TestSuite) string {
+		return SuiteKey(testSuite, params.ReduceTestSuitesBy)
+	}
+
+	if params.OutputPath == StdoutPath {
+		if params.Stdout == nil {
+			params.Stdout = os.Stdout
+		}
+		switch params.OutputFormat {
+		case enums.OutputFormatJSON:
+			return serialization.SerializeJSONToWriter(params.Stdout, testSuites, keyOf)
+		case enums.OutputFormatCSV, enums.OutputFormatTSV:
+			return serialization.SerializeTableToWriter(params.Stdout, testSuites, keyOf, params.OutputRows, tableComma(params.OutputFormat))
+		case enums.OutputFormatCTRF:
+			return serialization.SerializeCTRFToWriter(params.Stdout, testSuites)
+		}
+		return serialization.SerializeToWriter(params.Stdout, testSuites)
+	}
+
 	// Create output directory if it doesn't exist
-	err = os.MkdirAll(params.OutputPath, os.ModePerm)
+	err := os.MkdirAll(params.OutputPath, os.ModePerm)
 	if err != nil {
 		helpers.FatalMsg("failed to create output directory: %v", err)
 		return err
 	}
 
+	switch params.OutputFormat {
+	case enums.OutputFormatJSON:
+		return serialization.SerializeJSON(params.OutputPath, testSuites, keyOf)
+	case enums.OutputFormatCSV, enums.OutputFormatTSV:
+		return serialization.SerializeTable(params.OutputPath, testSuites, keyOf, params.OutputRows, tableComma(params.OutputFormat))
+	case enums.OutputFormatCTRF:
+		return serialization.SerializeCTRF(params.OutputPath, testSuites)
+	}
+
 	serialization.Serialize(params.OutputPath, testSuites)
 
 	return nil
 }
 
+func tableComma(format enums.OutputFormat) rune {
+	if format == enums.OutputFormatTSV {
+		return '\t'
+	}
+	return ','
+}
+
+// writeReportFile creates a report file, along with its directory, and
+// writes it with write.
+func writeReportFile(path string, write func(io.Writer) error) error {
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
 type SuiteFieldExtractor func(serialization.TestSuite) float64
 
 func SuiteTimeExtractor(ts serialization.TestSuite) float64 {
@@ -146,6 +241,12 @@ func reduceTestSuiteSlice(testSuiteSlice []serialization.TestSuite, params Reduc
 
 	testSuite.Time = reduceTestSuites(testSuiteSlice, SuiteTimeExtractor, params.OperationTestSuitesTime)
 
+	// Runs the reduced suite stands for, so it can be reduced again
+	testSuite.Samples = 0
+	for _, reducedSuite := range testSuiteSlice {
+		testSuite.Samples += serialization.SuiteWeight(reducedSuite)
+	}
+
 	// Tests count
 	reducedTests := reduceTestSuites(testSuiteSlice, SuiteTestsExtractor, params.OperationTestSuitesTests)
 	testSuite.Tests = roundToInt(reducedTests, params.RoundingMode)
@@ -177,7 +278,7 @@ func reduceTestCases(testSuiteSlice []serialization.TestSuite, reduceBy enums.Te
 
 	for _, testSuite := range testSuiteSlice {
 		for _, testCase := range testSuite.TestCases {
-			key := extractKeyFromCase(testCase, reduceBy)
+			key := CaseKey(testCase, reduceBy)
 			groupedCases[key] = append(groupedCases[key], testCase)
 		}
 	}
@@ -188,13 +289,36 @@ func reduceTestCases(testSuiteSlice []serialization.TestSuite, reduceBy enums.Te
 		baseCase := cases[0]
 		reducedTime := reduceTestCaseTimes(cases, operation)
 		baseCase.Time = reducedTime
+		baseCase.Samples = 0
+		baseCase.Outcomes = serialization.Outcomes{}
+		for _, reducedCase := range cases {
+			baseCase.Samples += serialization.CaseWeight(reducedCase)
+			baseCase.Outcomes = baseCase.Outcomes.Add(serialization.CaseOutcomes(reducedCase))
+		}
+		// The outcome of the first run doesn't describe the reduced case
+		baseCase.Failure = nil
+		baseCase.Error = nil
+		baseCase.Skipped = nil
+		baseCase.Properties = nil
 		reducedCases = append(reducedCases, baseCase)
 	}
 
 	return reducedCases
 }
 
-func extractKeyFromCase(testCase serialization.TestCase, reduceBy enums.TestCaseField) string {
+// SuiteKey is the key test suites are grouped and reduced by.
+func SuiteKey(testSuite serialization.TestSuite, reduceBy enums.TestSuiteField) string {
+	if reduceBy == enums.TestSuiteFieldNameFilepath {
+		return testSuite.File + ":" + testSuite.Name
+	} else if reduceBy == enums.TestSuiteFieldFilepath {
+		return testSuite.File
+	} else {
+		return testSuite.Name
+	}
+}
+
+// CaseKey is the key test cases are grouped and reduced by within a suite.
+func CaseKey(testCase serialization.TestCase, reduceBy enums.TestCaseField) string {
 	if reduceBy == enums.TestCaseFieldClassname {
 		return testCase.Classname
 	} else if reduceBy == enums.TestCaseFieldFile {
@@ -206,33 +330,53 @@ func extractKeyFromCase(testCase serialization.TestCase, reduceBy enums.TestCase
 
 func reduceTestCaseTimes(testCaseSlice []serialization.TestCase, operation enums.AggregateOperation) float64 {
 	slice := make([]float64, 0, len(testCaseSlice))
+	weights := make([]int, 0, len(testCaseSlice))
 	for _, testCase := range testCaseSlice {
 		slice = append(slice, testCase.Time)
+		weights = append(weights, serialization.CaseWeight(testCase))
 	}
-	return reduce(slice, operation)
+	return reduceWeighted(slice, weights, operation)
 }
 
 func reduceTestSuites(testSuiteSlice []serialization.TestSuite, extractor SuiteFieldExtractor, operation enums.AggregateOperation) float64 {
 	slice := make([]float64, 0, len(testSuiteSlice))
+	weights := make([]int, 0, len(testSuiteSlice))
 	for _, testSuite := range testSuiteSlice {
 		slice = append(slice, extractor(testSuite))
+		weights = append(weights, serialization.SuiteWeight(testSuite))
 	}
-	return reduce(slice, operation)
+	return reduceWeighted(slice, weights, operation)
 }
 
 func reduce(slice []float64, operation enums.AggregateOperation) float64 {
+	return reduceWeighted(slice, nil, operation)
+}
+
+// reduceWeighted reduces values that each stand for a number of runs, such
+// as the times of already reduced reports. Means, medians and modes count
+// each value once per run, while sums, minimums and maximums are unchanged,
+// so reducing reduced reports gives the same result as reducing every run.
+// A nil weights slice weighs every value as a single run.
+func reduceWeighted(slice []float64, weights []int, operation enums.AggregateOperation) float64 {
+	if weights == nil {
+		weights = make([]int, len(slice))
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+
 	if operation == enums.AggregateOperationMax {
 		return reduceMax(slice)
 	} else if operation == enums.AggregateOperationMin {
 		return reduceMin(slice)
 	} else if operation == enums.AggregateOperationMode {
-		return reduceMode(slice)
+		return reduceMode(slice, weights)
 	} else if operation == enums.AggregateOperationSum {
 		return reduceSum(slice)
 	} else if operation == enums.AggregateOperationMedian {
-		return reduceMedian(slice)
+		return reduceMedian(slice, weights)
 	} else {
-		return reduceMean(slice)
+		return reduceMean(slice, weights)
 	}
 }
 
@@ -252,19 +396,21 @@ func reduceMin(slice []float64) float64 {
 	return min
 }
 
-func reduceMean(slice []float64) float64 {
+func reduceMean(slice []float64, weights []int) float64 {
 	var total float64 = 0
-	for _, val := range slice {
-		total += val
+	var count int = 0
+	for i, val := range slice {
+		total += val * float64(weights[i])
+		count += weights[i]
 	}
-	mean := total / float64(len(slice))
+	mean := total / float64(count)
 	return mean
 }
 
-func reduceMode(slice []float64) float64 {
+func reduceMode(slice []float64, weights []int) float64 {
 	freqs := make(map[float64]int)
-	for _, val := range slice {
-		freqs[val]++
+	for i, val := range slice {
+		freqs[val] += weights[i]
 	}
 	var topVal float64 = 0
 	var topFreq int = 0
@@ -285,12 +431,28 @@ func reduceSum(slice []float64) float64 {
 	return total
 }
 
-func reduceMedian(slice []float64) float64 {
-	sortedSlice := make([]float64, len(slice))
-	copy(sortedSlice, slice)
-	sort.Float64s(sortedSlice)
-	medianIndex := medianIndex(len(sortedSlice))
-	return sortedSlice[medianIndex]
+// reduceMedian picks the median as if every value were repeated once per
+// run it stands for.
+func reduceMedian(slice []float64, weights []int) float64 {
+	indexes := make([]int, len(slice))
+	count := 0
+	for i := range indexes {
+		indexes[i] = i
+		count += weights[i]
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return slice[indexes[i]] < slice[indexes[j]]
+	})
+
+	medianIndex := medianIndex(count)
+	position := 0
+	for _, index := range indexes {
+		position += weights[index]
+		if position > medianIndex {
+			return slice[index]
+		}
+	}
+	return slice[indexes[len(indexes)-1]]
 }
 
 func medianIndex(sliceLength int) int {
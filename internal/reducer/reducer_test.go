@@ -1,12 +1,16 @@
 package reducer
 
 import (
+	"bytes"
 	"io"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
 	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+	"github.com/willgeorgetaylor/junit-reducer/internal/quarantine"
 	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
 )
 
@@ -127,8 +131,7 @@ func TestBasicReduce(t *testing.T) {
 	defer tearDown()
 
 	err := Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "fixtures/valid/*.xml",
-		ExcludeFilePattern:            "",
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldName,
@@ -197,8 +200,7 @@ func TestInvalidIncludeFilePattern(t *testing.T) {
 	defer tearDown()
 
 	err := Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "[^bc",
-		ExcludeFilePattern:            "",
+		IncludeFilePatterns:           []string{"[^bc"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldName,
@@ -221,8 +223,8 @@ func TestExcludeFilePattern(t *testing.T) {
 	defer tearDown()
 
 	err := Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "fixtures/valid/*.xml",
-		ExcludeFilePattern:            "fixtures/valid/Sample.xml",
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
+		ExcludeFilePatterns:           []string{"fixtures/valid/Sample.xml"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldName,
@@ -291,8 +293,8 @@ func TestInvalidExcludeFilePattern(t *testing.T) {
 	defer tearDown()
 
 	err := Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "fixtures/valid/*.xml",
-		ExcludeFilePattern:            "[^bc",
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
+		ExcludeFilePatterns:           []string{"[^bc"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldName,
@@ -315,8 +317,8 @@ func TestNoFilesFound(t *testing.T) {
 	defer tearDown()
 
 	err := Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "fixtures/valid/*.xml",
-		ExcludeFilePattern:            "fixtures/valid/*.xml",
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
+		ExcludeFilePatterns:           []string{"fixtures/valid/*.xml"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldName,
@@ -345,8 +347,7 @@ func TestUnreadableFile(t *testing.T) {
 	}
 
 	err = Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "fixtures/invalid/unreadable.xml",
-		ExcludeFilePattern:            "",
+		IncludeFilePatterns:           []string{"fixtures/invalid/unreadable.xml"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldName,
@@ -369,8 +370,7 @@ func TestUnparseableFile(t *testing.T) {
 	defer tearDown()
 
 	err := Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "fixtures/invalid/unparseable.xml",
-		ExcludeFilePattern:            "",
+		IncludeFilePatterns:           []string{"fixtures/invalid/unparseable.xml"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldName,
@@ -400,8 +400,7 @@ func TestOutputPathCannotBeCreated(t *testing.T) {
 	}
 
 	err = Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "fixtures/valid/*.xml",
-		ExcludeFilePattern:            "",
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldName,
@@ -424,8 +423,7 @@ func TestReduceTestSuitesByFilepath(t *testing.T) {
 	defer tearDown()
 
 	err := Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "fixtures/valid/*.xml",
-		ExcludeFilePattern:            "",
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldName,
@@ -494,8 +492,7 @@ func TestReduceTestCasesByClassName(t *testing.T) {
 	defer tearDown()
 
 	err := Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "fixtures/valid/*.xml",
-		ExcludeFilePattern:            "",
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldClassname,
@@ -548,8 +545,7 @@ func TestReduceTestCasesByFilename(t *testing.T) {
 	defer tearDown()
 
 	err := Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "fixtures/valid/*.xml",
-		ExcludeFilePattern:            "",
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldFile,
@@ -602,8 +598,7 @@ func TestMaxAggOperation(t *testing.T) {
 	defer tearDown()
 
 	err := Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "fixtures/valid/*.xml",
-		ExcludeFilePattern:            "",
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldName,
@@ -672,8 +667,7 @@ func TestMinAggOperation(t *testing.T) {
 	defer tearDown()
 
 	err := Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "fixtures/valid/*.xml",
-		ExcludeFilePattern:            "",
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldName,
@@ -742,8 +736,7 @@ func TestSumAggOperation(t *testing.T) {
 	defer tearDown()
 
 	err := Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "fixtures/valid/*.xml",
-		ExcludeFilePattern:            "",
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldName,
@@ -812,8 +805,7 @@ func TestMedianAggOperation(t *testing.T) {
 	defer tearDown()
 
 	err := Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "fixtures/valid/*.xml",
-		ExcludeFilePattern:            "",
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldClassname,
@@ -866,8 +858,7 @@ func TestModeAggOperation(t *testing.T) {
 	defer tearDown()
 
 	err := Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "fixtures/valid/*.xml",
-		ExcludeFilePattern:            "",
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldClassname,
@@ -921,8 +912,7 @@ func TestRoundingModeCeil(t *testing.T) {
 	defer tearDown()
 
 	err := Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "fixtures/valid/*.xml",
-		ExcludeFilePattern:            "",
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldName,
@@ -991,8 +981,7 @@ func TestRoundingModeFloor(t *testing.T) {
 	defer tearDown()
 
 	err := Reduce(ReduceFunctionParams{
-		IncludeFilePattern:            "fixtures/valid/*.xml",
-		ExcludeFilePattern:            "",
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
 		OutputPath:                    "output/",
 		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
 		ReduceTestCasesBy:             enums.TestCaseFieldName,
@@ -1055,3 +1044,614 @@ func TestRoundingModeFloor(t *testing.T) {
 		},
 	)
 }
+
+func TestIncludeFromStdin(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	err := Reduce(ReduceFunctionParams{
+		IncludeFilePatterns:           []string{"-"},
+		ExcludeFilePatterns:           []string{"fixtures/valid/Sample.xml"},
+		Stdin:                         strings.NewReader("fixtures/valid/Sample.xml\nfixtures/valid/Sample2.xml\n"),
+		OutputPath:                    "output/",
+		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
+		ReduceTestCasesBy:             enums.TestCaseFieldName,
+		OperationTestSuitesTests:      enums.AggregateOperationMean,
+		OperationTestSuitesFailed:     enums.AggregateOperationMean,
+		OperationTestSuitesErrors:     enums.AggregateOperationMean,
+		OperationTestSuitesSkipped:    enums.AggregateOperationMean,
+		OperationTestSuitesAssertions: enums.AggregateOperationMean,
+		OperationTestSuitesTime:       enums.AggregateOperationMean,
+		OperationTestCasesTime:        enums.AggregateOperationMean,
+		RoundingMode:                  enums.RoundingModeRound,
+	})
+
+	if err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+
+	if helpers.FileExists("output/Sample.xml") {
+		t.Errorf("expected excluded file 'output/Sample.xml' not to exist")
+	}
+
+	if !helpers.FileExists("output/Sample2.xml") {
+		t.Errorf("expected output file 'output/Sample2.xml' to exist")
+	}
+}
+
+func TestFilesFromNulSeparatedList(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	err := Reduce(ReduceFunctionParams{
+		FilesFrom:                     "-",
+		Stdin:                         strings.NewReader("fixtures/valid/Sample.xml\x00fixtures/valid/Sample2.xml\x00"),
+		OutputPath:                    "output/",
+		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
+		ReduceTestCasesBy:             enums.TestCaseFieldName,
+		OperationTestSuitesTests:      enums.AggregateOperationMean,
+		OperationTestSuitesFailed:     enums.AggregateOperationMean,
+		OperationTestSuitesErrors:     enums.AggregateOperationMean,
+		OperationTestSuitesSkipped:    enums.AggregateOperationMean,
+		OperationTestSuitesAssertions: enums.AggregateOperationMean,
+		OperationTestSuitesTime:       enums.AggregateOperationMean,
+		OperationTestCasesTime:        enums.AggregateOperationMean,
+		RoundingMode:                  enums.RoundingModeRound,
+	})
+
+	if err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+
+	// Both reports share a suite, which is reduced into the first report
+	if !helpers.FileExists("output/Sample.xml") {
+		t.Errorf("expected output file 'output/Sample.xml' to exist")
+	}
+}
+
+func TestReportsFromStdin(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	sample, err := os.ReadFile("fixtures/valid/Sample.xml")
+	if err != nil {
+		t.Errorf("Failed to read fixture: %v", err)
+	}
+
+	sample2, err := os.ReadFile("fixtures/valid/Sample2.xml")
+	if err != nil {
+		t.Errorf("Failed to read fixture: %v", err)
+	}
+
+	err = Reduce(ReduceFunctionParams{
+		ReadReportsFromStdin:          true,
+		Stdin:                         bytes.NewReader(append(append(sample, '\n'), sample2...)),
+		OutputPath:                    "output/",
+		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
+		ReduceTestCasesBy:             enums.TestCaseFieldName,
+		OperationTestSuitesTests:      enums.AggregateOperationMean,
+		OperationTestSuitesFailed:     enums.AggregateOperationMean,
+		OperationTestSuitesErrors:     enums.AggregateOperationMean,
+		OperationTestSuitesSkipped:    enums.AggregateOperationMean,
+		OperationTestSuitesAssertions: enums.AggregateOperationMean,
+		OperationTestSuitesTime:       enums.AggregateOperationMean,
+		OperationTestCasesTime:        enums.AggregateOperationMean,
+		RoundingMode:                  enums.RoundingModeRound,
+	})
+
+	if err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+
+	output, err := os.ReadFile("output/stdin.xml")
+	if err != nil {
+		t.Errorf("error reading output file 'output/stdin.xml'")
+	}
+
+	xmlTestSuites, err := serialization.UnmarshalTestSuites(output, "stdin.xml")
+	if err != nil {
+		t.Errorf("error parsing JUnit XML from output file 'stdin.xml'")
+	}
+
+	if len(xmlTestSuites.TestSuites) != 1 {
+		t.Fatalf("expected 1 reduced test suite, got %d", len(xmlTestSuites.TestSuites))
+	}
+
+	if xmlTestSuites.TestSuites[0].Time != 49.09959481199999 {
+		t.Errorf("expected reduced test suite time of %f seconds, got %f", 49.09959481199999, xmlTestSuites.TestSuites[0].Time)
+	}
+}
+
+func TestStdinUsedTwice(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	err := Reduce(ReduceFunctionParams{
+		IncludeFilePatterns:           []string{"-"},
+		ReadReportsFromStdin:          true,
+		Stdin:                         strings.NewReader(""),
+		OutputPath:                    "output/",
+		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
+		ReduceTestCasesBy:             enums.TestCaseFieldName,
+		OperationTestSuitesTests:      enums.AggregateOperationMean,
+		OperationTestSuitesFailed:     enums.AggregateOperationMean,
+		OperationTestSuitesErrors:     enums.AggregateOperationMean,
+		OperationTestSuitesSkipped:    enums.AggregateOperationMean,
+		OperationTestSuitesAssertions: enums.AggregateOperationMean,
+		OperationTestSuitesTime:       enums.AggregateOperationMean,
+		OperationTestCasesTime:        enums.AggregateOperationMean,
+	})
+
+	if err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+func TestOutputToStdout(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	var stdout bytes.Buffer
+
+	err := Reduce(ReduceFunctionParams{
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
+		OutputPath:                    "-",
+		Stdout:                        &stdout,
+		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
+		ReduceTestCasesBy:             enums.TestCaseFieldName,
+		OperationTestSuitesTests:      enums.AggregateOperationMean,
+		OperationTestSuitesFailed:     enums.AggregateOperationMean,
+		OperationTestSuitesErrors:     enums.AggregateOperationMean,
+		OperationTestSuitesSkipped:    enums.AggregateOperationMean,
+		OperationTestSuitesAssertions: enums.AggregateOperationMean,
+		OperationTestSuitesTime:       enums.AggregateOperationMean,
+		OperationTestCasesTime:        enums.AggregateOperationMean,
+		RoundingMode:                  enums.RoundingModeRound,
+	})
+
+	if err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+
+	if helpers.DirExists("-") || helpers.FileExists("-") {
+		t.Errorf("expected no '-' output directory to be created")
+	}
+
+	xmlTestSuites, err := serialization.UnmarshalTestSuites(stdout.Bytes(), "stdout")
+	if err != nil {
+		t.Errorf("error parsing JUnit XML from stdout: %v", err)
+	}
+
+	if len(xmlTestSuites.TestSuites) != 1 {
+		t.Fatalf("expected 1 reduced test suite, got %d", len(xmlTestSuites.TestSuites))
+	}
+
+	if xmlTestSuites.TestSuites[0].Tests != 5 {
+		t.Errorf("expected reduced test suite to report %d tests, got %d", 5, xmlTestSuites.TestSuites[0].Tests)
+	}
+}
+
+func TestSplitPatterns(t *testing.T) {
+	actualPatterns := SplitPatterns([]string{"unit/**/*.xml, e2e/{chrome,firefox}/*.xml", "!e2e/**/flaky.xml", ""})
+	expectedPatterns := []string{"unit/**/*.xml", "e2e/{chrome,firefox}/*.xml", "!e2e/**/flaky.xml"}
+
+	if !reflect.DeepEqual(actualPatterns, expectedPatterns) {
+		t.Errorf("expected patterns %v, got %v", expectedPatterns, actualPatterns)
+	}
+}
+
+func TestNegatedIncludeFilePattern(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	err := Reduce(ReduceFunctionParams{
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml,!fixtures/valid/Sample.xml", "fixtures/invalid/none.xml"},
+		OutputPath:                    "output/",
+		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
+		ReduceTestCasesBy:             enums.TestCaseFieldName,
+		OperationTestSuitesTests:      enums.AggregateOperationMean,
+		OperationTestSuitesFailed:     enums.AggregateOperationMean,
+		OperationTestSuitesErrors:     enums.AggregateOperationMean,
+		OperationTestSuitesSkipped:    enums.AggregateOperationMean,
+		OperationTestSuitesAssertions: enums.AggregateOperationMean,
+		OperationTestSuitesTime:       enums.AggregateOperationMean,
+		OperationTestCasesTime:        enums.AggregateOperationMean,
+		RoundingMode:                  enums.RoundingModeRound,
+	})
+
+	if err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+
+	if helpers.FileExists("output/Sample.xml") {
+		t.Errorf("expected negated file 'output/Sample.xml' not to exist")
+	}
+
+	if !helpers.FileExists("output/Sample2.xml") {
+		t.Errorf("expected output file 'output/Sample2.xml' to exist")
+	}
+}
+
+func TestNegatedExcludeFilePattern(t *testing.T) {
+	_, err := FindReportPaths(ReportInput{
+		IncludeFilePatterns: []string{"fixtures/valid/*.xml"},
+		ExcludeFilePatterns: []string{"fixtures/valid/Sample2.xml,!fixtures/valid/Sample.xml"},
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "can't be negated") {
+		t.Errorf("expected an error for a negated exclude pattern, got %v", err)
+	}
+}
+
+func TestMultipleExcludeFilePatterns(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	err := Reduce(ReduceFunctionParams{
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
+		ExcludeFilePatterns:           []string{"fixtures/valid/Sample.xml", "fixtures/valid/Sample2.xml"},
+		OutputPath:                    "output/",
+		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
+		ReduceTestCasesBy:             enums.TestCaseFieldName,
+		OperationTestSuitesTests:      enums.AggregateOperationMean,
+		OperationTestSuitesFailed:     enums.AggregateOperationMean,
+		OperationTestSuitesErrors:     enums.AggregateOperationMean,
+		OperationTestSuitesSkipped:    enums.AggregateOperationMean,
+		OperationTestSuitesAssertions: enums.AggregateOperationMean,
+		OperationTestSuitesTime:       enums.AggregateOperationMean,
+		OperationTestCasesTime:        enums.AggregateOperationMean,
+	})
+
+	if err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+func TestReduceWeighted(t *testing.T) {
+	values := []float64{10, 2, 4}
+	weights := []int{1, 5, 2}
+
+	tests := map[enums.AggregateOperation]float64{
+		enums.AggregateOperationMean:   28.0 / 8.0,
+		enums.AggregateOperationSum:    16,
+		enums.AggregateOperationMin:    2,
+		enums.AggregateOperationMax:    10,
+		enums.AggregateOperationMedian: 2,
+		enums.AggregateOperationMode:   2,
+	}
+	for operation, expected := range tests {
+		if actual := reduceWeighted(values, weights, operation); actual != expected {
+			t.Errorf("expected weighted %s of %v, got %v", enums.AggregateOperationKeys[operation], expected, actual)
+		}
+	}
+
+	if actual := reduceWeighted(values, nil, enums.AggregateOperationMedian); actual != 4 {
+		t.Errorf("expected unweighted median of 4, got %v", actual)
+	}
+}
+
+func TestReduceReducedSuitesComposes(t *testing.T) {
+	params := ReduceFunctionParams{
+		OperationTestSuitesTime: enums.AggregateOperationMean,
+		OperationTestCasesTime:  enums.AggregateOperationMean,
+		ReduceTestCasesBy:       enums.TestCaseFieldName,
+	}
+
+	// Two branches, reduced from 2 and 6 runs
+	branches := []serialization.TestSuite{
+		{Name: "UserTest", Time: 10, Samples: 2, TestCases: []serialization.TestCase{{Name: "test_create", Time: 10, Samples: 2}}},
+		{Name: "UserTest", Time: 2, Samples: 6, TestCases: []serialization.TestCase{{Name: "test_create", Time: 2, Samples: 6}}},
+	}
+
+	reduced := reduceTestSuiteSlice(branches, params)[0]
+
+	if reduced.Time != 4 || reduced.Samples != 8 {
+		t.Errorf("expected the runs of both branches to be averaged to 4 over 8 samples, got %v over %d", reduced.Time, reduced.Samples)
+	}
+	if reduced.TestCases[0].Time != 4 || reduced.TestCases[0].Samples != 8 {
+		t.Errorf("expected the reduced case to average to 4 over 8 samples, got %+v", reduced.TestCases[0])
+	}
+
+	// Reports that were never reduced count as a single run each
+	runs := []serialization.TestSuite{{Name: "UserTest", Time: 1}, {Name: "UserTest", Time: 3}}
+	if reduced := reduceTestSuiteSlice(runs, params)[0]; reduced.Samples != 2 || reduced.Time != 2 {
+		t.Errorf("expected 2 samples averaging 2, got %v over %d", reduced.Time, reduced.Samples)
+	}
+}
+
+func TestFlakyCases(t *testing.T) {
+	var stdout bytes.Buffer
+	flakyReportPath := t.TempDir() + "/flaky/report.json"
+
+	err := Reduce(ReduceFunctionParams{
+		IncludeFilePatterns:           []string{"fixtures/flaky/*.xml"},
+		OutputPath:                    "-",
+		Stdout:                        &stdout,
+		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
+		ReduceTestCasesBy:             enums.TestCaseFieldName,
+		OperationTestSuitesTests:      enums.AggregateOperationMean,
+		OperationTestSuitesFailed:     enums.AggregateOperationMean,
+		OperationTestSuitesErrors:     enums.AggregateOperationMean,
+		OperationTestSuitesSkipped:    enums.AggregateOperationMean,
+		OperationTestSuitesAssertions: enums.AggregateOperationMean,
+		OperationTestSuitesTime:       enums.AggregateOperationMean,
+		OperationTestCasesTime:        enums.AggregateOperationMean,
+		RoundingMode:                  enums.RoundingModeRound,
+		FlakyReportPath:               flakyReportPath,
+		FlakyReportFormat:             enums.ReportFormatJSON,
+		FlakyProperty:                 true,
+	})
+
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if count := strings.Count(stdout.String(), "<properties>"); count != 1 {
+		t.Errorf("expected only the flaky case to have a properties element, got %d", count)
+	}
+
+	xmlTestSuites, err := serialization.UnmarshalTestSuites(stdout.Bytes(), "stdout")
+	if err != nil {
+		t.Fatalf("error parsing JUnit XML from stdout: %v", err)
+	}
+
+	for _, testCase := range xmlTestSuites.TestSuites[0].TestCases {
+		if testCase.Failure != nil || testCase.Error != nil || testCase.Skipped != nil {
+			t.Errorf("expected the outcome of reduced case '%s' to be cleared", testCase.Name)
+		}
+
+		expectedProperties := serialization.Properties(nil)
+		if testCase.Name == "test_update" {
+			expectedProperties = serialization.Properties{{Name: "flaky-rate", Value: "0.6667"}}
+		}
+		if !reflect.DeepEqual(testCase.Properties, expectedProperties) {
+			t.Errorf("expected case '%s' to have properties %v, got %v", testCase.Name, expectedProperties, testCase.Properties)
+		}
+	}
+
+	report, err := os.ReadFile(flakyReportPath)
+	if err != nil {
+		t.Fatalf("expected flaky report to be written, got %s", err)
+	}
+	for _, expected := range []string{`"flaky": 1`, `"name": "test_update"`, `"passed": 1`, `"failed": 1`, `"errored": 1`, `"skipped": 1`} {
+		if !strings.Contains(string(report), expected) {
+			t.Errorf("expected flaky report to contain %s, got %s", expected, report)
+		}
+	}
+}
+
+func TestXMLOutputKeepsOutcomes(t *testing.T) {
+	outputPath := t.TempDir()
+
+	params := ReduceFunctionParams{
+		IncludeFilePatterns:           []string{"fixtures/flaky/*.xml"},
+		OutputPath:                    outputPath,
+		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
+		ReduceTestCasesBy:             enums.TestCaseFieldName,
+		OperationTestSuitesTests:      enums.AggregateOperationMean,
+		OperationTestSuitesFailed:     enums.AggregateOperationMean,
+		OperationTestSuitesErrors:     enums.AggregateOperationMean,
+		OperationTestSuitesSkipped:    enums.AggregateOperationMean,
+		OperationTestSuitesAssertions: enums.AggregateOperationMean,
+		OperationTestSuitesTime:       enums.AggregateOperationMean,
+		OperationTestCasesTime:        enums.AggregateOperationMean,
+		RoundingMode:                  enums.RoundingModeRound,
+	}
+
+	if err := Reduce(params); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	var stdout bytes.Buffer
+	params.IncludeFilePatterns = []string{outputPath + "/*.xml"}
+	params.OutputPath = "-"
+	params.Stdout = &stdout
+
+	if err := Reduce(params); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	xmlTestSuites, err := serialization.UnmarshalTestSuites(stdout.Bytes(), "stdout")
+	if err != nil {
+		t.Fatalf("error parsing JUnit XML from stdout: %v", err)
+	}
+	expectedOutcomes := map[string]serialization.Outcomes{
+		"test_create":  {Passed: 4},
+		"test_update":  {Passed: 1, Failed: 1, Errored: 1, Skipped: 1},
+		"test_destroy": {Failed: 4},
+	}
+	for _, testCase := range xmlTestSuites.TestSuites[0].TestCases {
+		expected := expectedOutcomes[testCase.Name]
+		if testCase.Outcomes != expected {
+			t.Errorf("expected case '%s' to keep outcomes %+v when reduced again, got %+v", testCase.Name, expected, testCase.Outcomes)
+		}
+	}
+}
+
+func TestJSONOutputReducesAgain(t *testing.T) {
+	outputPath := t.TempDir()
+
+	params := ReduceFunctionParams{
+		IncludeFilePatterns:           []string{"fixtures/valid/*.xml"},
+		OutputPath:                    outputPath,
+		OutputFormat:                  enums.OutputFormatJSON,
+		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
+		ReduceTestCasesBy:             enums.TestCaseFieldName,
+		OperationTestSuitesTests:      enums.AggregateOperationMean,
+		OperationTestSuitesFailed:     enums.AggregateOperationMean,
+		OperationTestSuitesErrors:     enums.AggregateOperationMean,
+		OperationTestSuitesSkipped:    enums.AggregateOperationMean,
+		OperationTestSuitesAssertions: enums.AggregateOperationMean,
+		OperationTestSuitesTime:       enums.AggregateOperationMean,
+		OperationTestCasesTime:        enums.AggregateOperationMean,
+		RoundingMode:                  enums.RoundingModeRound,
+	}
+
+	if err := Reduce(params); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if !helpers.FileExists(outputPath + "/Sample.json") {
+		t.Fatalf("expected output file '%s/Sample.json' to exist", outputPath)
+	}
+
+	var stdout bytes.Buffer
+	params.IncludeFilePatterns = []string{outputPath + "/*.json"}
+	params.OutputPath = "-"
+	params.OutputFormat = enums.OutputFormatXML
+	params.Stdout = &stdout
+
+	if err := Reduce(params); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	xmlTestSuites, err := serialization.UnmarshalTestSuites(stdout.Bytes(), "stdout")
+	if err != nil {
+		t.Fatalf("error parsing JUnit XML from stdout: %v", err)
+	}
+	testSuite := xmlTestSuites.TestSuites[0]
+	if testSuite.Time != 49.09959481199999 || testSuite.Samples != 2 {
+		t.Errorf("expected the JSON report to reduce to the same time over 2 samples, got %v over %d", testSuite.Time, testSuite.Samples)
+	}
+}
+
+func TestCTRFOutputKeepsOutcomes(t *testing.T) {
+	directPath := t.TempDir()
+	outputPath := t.TempDir()
+	roundTripPath := t.TempDir()
+
+	params := ReduceFunctionParams{
+		IncludeFilePatterns:           []string{"fixtures/flaky/*.xml"},
+		OutputPath:                    outputPath,
+		OutputFormat:                  enums.OutputFormatCTRF,
+		ReduceTestSuitesBy:            enums.TestSuiteFieldNameFilepath,
+		ReduceTestCasesBy:             enums.TestCaseFieldName,
+		OperationTestSuitesTests:      enums.AggregateOperationMean,
+		OperationTestSuitesFailed:     enums.AggregateOperationMean,
+		OperationTestSuitesErrors:     enums.AggregateOperationMean,
+		OperationTestSuitesSkipped:    enums.AggregateOperationMean,
+		OperationTestSuitesAssertions: enums.AggregateOperationMean,
+		OperationTestSuitesTime:       enums.AggregateOperationMean,
+		OperationTestCasesTime:        enums.AggregateOperationMean,
+		RoundingMode:                  enums.RoundingModeRound,
+		FlakyReportPath:               directPath + "/flaky.json",
+		FlakyReportFormat:             enums.ReportFormatJSON,
+		QuarantineFilePath:            directPath + "/quarantine.json",
+		QuarantineFormat:              enums.QuarantineFormatJSON,
+		QuarantineThresholds:          quarantine.Thresholds{FailureRate: 0.5, FlakyRate: 0.5},
+	}
+
+	if err := Reduce(params); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	var stdout bytes.Buffer
+	params.IncludeFilePatterns = []string{outputPath + "/*.json"}
+	params.OutputPath = "-"
+	params.OutputFormat = enums.OutputFormatXML
+	params.Stdout = &stdout
+	params.FlakyReportPath = roundTripPath + "/flaky.json"
+	params.QuarantineFilePath = roundTripPath + "/quarantine.json"
+
+	if err := Reduce(params); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	for _, name := range []string{"flaky.json", "quarantine.json"} {
+		direct, err := os.ReadFile(directPath + "/" + name)
+		if err != nil {
+			t.Fatalf("expected %s to be written, got %s", name, err)
+		}
+		roundTrip, err := os.ReadFile(roundTripPath + "/" + name)
+		if err != nil {
+			t.Fatalf("expected %s to be written, got %s", name, err)
+		}
+		if !bytes.Equal(direct, roundTrip) {
+			t.Errorf("expected %s to be unchanged by the CTRF round trip, got %s, want %s", name, roundTrip, direct)
+		}
+	}
+}
+
+func TestReduceGoTestReports(t *testing.T) {
+	var stdout bytes.Buffer
+	params := ReduceFunctionParams{
+		IncludeFilePatterns:           []string{"fixtures/gotest/*.json"},
+		OutputPath:                    "-",
+		Stdout:                        &stdout,
+		ReduceTestSuitesBy:            enums.TestSuiteFieldName,
+		ReduceTestCasesBy:             enums.TestCaseFieldName,
+		OperationTestSuitesTests:      enums.AggregateOperationMean,
+		OperationTestSuitesFailed:     enums.AggregateOperationMean,
+		OperationTestSuitesErrors:     enums.AggregateOperationMean,
+		OperationTestSuitesSkipped:    enums.AggregateOperationMean,
+		OperationTestSuitesAssertions: enums.AggregateOperationMean,
+		OperationTestSuitesTime:       enums.AggregateOperationMean,
+		OperationTestCasesTime:        enums.AggregateOperationMean,
+		RoundingMode:                  enums.RoundingModeRound,
+	}
+
+	if err := Reduce(params); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	xmlTestSuites, err := serialization.UnmarshalTestSuites(stdout.Bytes(), "stdout")
+	if err != nil {
+		t.Fatalf("error parsing JUnit XML from stdout: %v", err)
+	}
+	if len(xmlTestSuites.TestSuites) != 1 {
+		t.Fatalf("expected 1 reduced test suite, got %d", len(xmlTestSuites.TestSuites))
+	}
+	testSuite := xmlTestSuites.TestSuites[0]
+	if testSuite.Name != "example.com/user" || testSuite.Time != 3.25 || testSuite.Tests != 2 || testSuite.Samples != 2 {
+		t.Errorf("expected the package reduced over 2 runs, got %+v", testSuite)
+	}
+	for _, testCase := range testSuite.TestCases {
+		if testCase.Name == "TestUpdate" && testCase.Time != 1 {
+			t.Errorf("expected TestUpdate time 1, got %v", testCase.Time)
+		}
+	}
+}
+
+func TestReduceGoTestRunsRepeatedWithCount(t *testing.T) {
+	var stdout bytes.Buffer
+	params := ReduceFunctionParams{
+		IncludeFilePatterns:           []string{"fixtures/gotest-count/*.json"},
+		OutputPath:                    "-",
+		Stdout:                        &stdout,
+		ReduceTestSuitesBy:            enums.TestSuiteFieldName,
+		ReduceTestCasesBy:             enums.TestCaseFieldName,
+		OperationTestSuitesTests:      enums.AggregateOperationMean,
+		OperationTestSuitesFailed:     enums.AggregateOperationMean,
+		OperationTestSuitesErrors:     enums.AggregateOperationMean,
+		OperationTestSuitesSkipped:    enums.AggregateOperationMean,
+		OperationTestSuitesAssertions: enums.AggregateOperationMean,
+		OperationTestSuitesTime:       enums.AggregateOperationMean,
+		OperationTestCasesTime:        enums.AggregateOperationMean,
+		RoundingMode:                  enums.RoundingModeRound,
+	}
+
+	if err := Reduce(params); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	xmlTestSuites, err := serialization.UnmarshalTestSuites(stdout.Bytes(), "stdout")
+	if err != nil {
+		t.Fatalf("error parsing JUnit XML from stdout: %v", err)
+	}
+
+	// Each run of `go test -count=2` is a case of its own, so a test that
+	// failed and then passed is reduced as flaky.
+	expectedCases := map[string]serialization.TestCase{
+		"TestCreate": {Time: 1.5, Samples: 2, Outcomes: serialization.Outcomes{Passed: 1, Failed: 1}},
+		"TestUpdate": {Time: 0.5, Samples: 2, Outcomes: serialization.Outcomes{Passed: 2}},
+	}
+	testCases := xmlTestSuites.TestSuites[0].TestCases
+	if len(testCases) != len(expectedCases) {
+		t.Fatalf("expected %d reduced cases, got %d", len(expectedCases), len(testCases))
+	}
+	for _, testCase := range testCases {
+		expected := expectedCases[testCase.Name]
+		if testCase.Time != expected.Time || testCase.Samples != expected.Samples || testCase.Outcomes != expected.Outcomes {
+			t.Errorf("expected case '%s' with time %v over %d runs and outcomes %+v, got time %v over %d runs and outcomes %+v",
+				testCase.Name, expected.Time, expected.Samples, expected.Outcomes, testCase.Time, testCase.Samples, testCase.Outcomes)
+		}
+	}
+}
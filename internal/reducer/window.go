@@ -0,0 +1,102 @@
+package reducer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+var relativeBoundPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)([smhdw])$`)
+
+var relativeBoundUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// Layouts accepted for absolute bounds and suite timestamps. JUnit reports
+// usually omit the timezone, in which case UTC is assumed.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// ParseTimeBound parses a --since/--until value, either an absolute
+// timestamp or a duration relative to now such as "7d" or "12h".
+func ParseTimeBound(value string, now time.Time) (time.Time, error) {
+	if match := relativeBoundPattern.FindStringSubmatch(value); match != nil {
+		amount, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		offset := time.Duration(amount * float64(relativeBoundUnits[match[2]]))
+		return now.Add(-offset), nil
+	}
+
+	if parsed, ok := parseTimestamp(value); ok {
+		return parsed, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time '%s', expected a timestamp like 2024-01-31T08:00:00Z or a relative time like 7d", value)
+}
+
+func parseTimestamp(value string) (time.Time, bool) {
+	for _, layout := range timestampLayouts {
+		parsed, err := time.Parse(layout, value)
+		if err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// suiteRunTime is when the suite ran, taken from its timestamp attribute or
+// otherwise the modification time of its report file. The zero time means
+// neither is known.
+func suiteRunTime(testSuite serialization.TestSuite) time.Time {
+	if parsed, ok := parseTimestamp(testSuite.Timestamp); ok {
+		return parsed
+	}
+	return testSuite.ModTime
+}
+
+// filterByTimeWindow drops suites that ran outside of the since/until bounds.
+// Zero bounds are open, and suites without a known run time are kept.
+func filterByTimeWindow(testSuites []serialization.TestSuite, since time.Time, until time.Time) []serialization.TestSuite {
+	filtered := make([]serialization.TestSuite, 0, len(testSuites))
+	for _, testSuite := range testSuites {
+		runTime := suiteRunTime(testSuite)
+		if !runTime.IsZero() {
+			if !since.IsZero() && runTime.Before(since) {
+				continue
+			}
+			if !until.IsZero() && runTime.After(until) {
+				continue
+			}
+		}
+		filtered = append(filtered, testSuite)
+	}
+	return filtered
+}
+
+// lastNRuns keeps the n most recent suites of a group.
+func lastNRuns(testSuiteSlice []serialization.TestSuite, n int) []serialization.TestSuite {
+	if n <= 0 || len(testSuiteSlice) <= n {
+		return testSuiteSlice
+	}
+
+	sorted := make([]serialization.TestSuite, len(testSuiteSlice))
+	copy(sorted, testSuiteSlice)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return suiteRunTime(sorted[i]).After(suiteRunTime(sorted[j]))
+	})
+	return sorted[:n]
+}
@@ -0,0 +1,84 @@
+package reducer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2024, 1, 31, 8, 0, 0, 0, time.UTC)
+
+	expectedBounds := map[string]time.Time{
+		"7d":                        now.Add(-7 * 24 * time.Hour),
+		"12h":                       now.Add(-12 * time.Hour),
+		"1.5w":                      now.Add(-252 * time.Hour),
+		"2024-01-01":                time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		"2024-01-01T12:30:00":       time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC),
+		"2024-01-01T12:30:00+01:00": time.Date(2024, 1, 1, 11, 30, 0, 0, time.UTC),
+	}
+
+	for value, expectedBound := range expectedBounds {
+		actualBound, err := ParseTimeBound(value, now)
+		if err != nil {
+			t.Errorf("expected no error for '%s', got %s", value, err)
+		}
+		if !actualBound.Equal(expectedBound) {
+			t.Errorf("expected '%s' to parse as %v, got %v", value, expectedBound, actualBound)
+		}
+	}
+
+	_, err := ParseTimeBound("last tuesday", now)
+	if err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+func TestFilterByTimeWindow(t *testing.T) {
+	testSuites := []serialization.TestSuite{
+		{Name: "old", Timestamp: "2024-01-01T00:00:00"},
+		{Name: "recent", Timestamp: "2024-01-30T00:00:00"},
+		{Name: "future", Timestamp: "2024-02-10T00:00:00"},
+		{Name: "modified", ModTime: time.Date(2024, 1, 29, 0, 0, 0, 0, time.UTC)},
+		{Name: "unknown"},
+	}
+
+	since := time.Date(2024, 1, 24, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	filtered := filterByTimeWindow(testSuites, since, until)
+	expectedNames := []string{"recent", "modified", "unknown"}
+
+	if len(filtered) != len(expectedNames) {
+		t.Fatalf("expected %d test suites, got %d", len(expectedNames), len(filtered))
+	}
+
+	for i, expectedName := range expectedNames {
+		if filtered[i].Name != expectedName {
+			t.Errorf("expected test suite '%s', got '%s'", expectedName, filtered[i].Name)
+		}
+	}
+}
+
+func TestLastNRuns(t *testing.T) {
+	testSuites := []serialization.TestSuite{
+		{Time: 1, Timestamp: "2024-01-01T00:00:00"},
+		{Time: 3, Timestamp: "2024-01-03T00:00:00"},
+		{Time: 2, Timestamp: "2024-01-02T00:00:00"},
+	}
+
+	recent := lastNRuns(testSuites, 2)
+
+	if len(recent) != 2 {
+		t.Fatalf("expected 2 test suites, got %d", len(recent))
+	}
+
+	if recent[0].Time != 3 || recent[1].Time != 2 {
+		t.Errorf("expected the two most recent runs, got times %f and %f", recent[0].Time, recent[1].Time)
+	}
+
+	if len(lastNRuns(testSuites, 0)) != 3 {
+		t.Errorf("expected all runs to be kept when n is 0")
+	}
+}
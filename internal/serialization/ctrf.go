@@ -0,0 +1,245 @@
+package serialization
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+)
+
+// CTRFReport is a Common Test Report Format report, which lists tests without
+// suites.
+type CTRFReport struct {
+	ReportFormat string      `json:"reportFormat,omitempty"`
+	SpecVersion  string      `json:"specVersion,omitempty"`
+	Results      CTRFResults `json:"results"`
+}
+
+type CTRFResults struct {
+	Tool    CTRFTool    `json:"tool"`
+	Summary CTRFSummary `json:"summary"`
+	Tests   []CTRFTest  `json:"tests"`
+}
+
+type CTRFTool struct {
+	Name string `json:"name"`
+}
+
+type CTRFSummary struct {
+	Tests   int   `json:"tests"`
+	Passed  int   `json:"passed"`
+	Failed  int   `json:"failed"`
+	Pending int   `json:"pending"`
+	Skipped int   `json:"skipped"`
+	Other   int   `json:"other"`
+	Start   int64 `json:"start"`
+	Stop    int64 `json:"stop"`
+}
+
+type CTRFTest struct {
+	Name     string  `json:"name"`
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration"`
+	// Suite is a string, or the path of nested suites in newer versions.
+	Suite    json.RawMessage `json:"suite,omitempty"`
+	FilePath string          `json:"filePath,omitempty"`
+	Line     int             `json:"line,omitempty"`
+	Message  string          `json:"message,omitempty"`
+	Trace    string          `json:"trace,omitempty"`
+	Flaky    bool            `json:"flaky,omitempty"`
+	Extra    *CTRFExtra      `json:"extra,omitempty"`
+}
+
+// CTRFExtra carries the number of runs a reduced case was reduced from and
+// their outcomes, so CTRF reports can be reduced again.
+type CTRFExtra struct {
+	Samples  int       `json:"samples,omitempty"`
+	Outcomes *Outcomes `json:"outcomes,omitempty"`
+}
+
+// suiteName reads the suite of a test, joining nested suites with " > ".
+func (test CTRFTest) suiteName() string {
+	var name string
+	if json.Unmarshal(test.Suite, &name) == nil {
+		return name
+	}
+	var path []string
+	if json.Unmarshal(test.Suite, &path) == nil {
+		return strings.Join(path, " > ")
+	}
+	return ""
+}
+
+// FromCTRF converts the tests of a CTRF report to suites, grouped by their
+// suite, or by their file when they have none.
+func FromCTRF(report CTRFReport, fileName string) []TestSuite {
+	timestamp := ""
+	if report.Results.Summary.Start > 0 {
+		timestamp = time.UnixMilli(report.Results.Summary.Start).UTC().Format("2006-01-02T15:04:05")
+	}
+
+	suites := make(map[string]*TestSuite)
+	var order []string
+	for _, test := range report.Results.Tests {
+		suiteName := test.suiteName()
+		if suiteName == "" {
+			suiteName = test.FilePath
+		}
+		suite, ok := suites[suiteName]
+		if !ok {
+			suite = &TestSuite{Name: suiteName, File: test.FilePath, FileName: fileName, Timestamp: timestamp}
+			suites[suiteName] = suite
+			order = append(order, suiteName)
+		}
+
+		testCase := TestCase{
+			Name:      test.Name,
+			Classname: suiteName,
+			File:      test.FilePath,
+			Line:      test.Line,
+			Time:      test.Duration / 1000,
+		}
+		if test.Extra != nil {
+			testCase.Samples = test.Extra.Samples
+		}
+		switch test.Status {
+		case "failed":
+			testCase.Failure = &Result{Message: test.Message, Text: test.Trace}
+			testCase.Outcomes.Failed = testCase.Samples
+			suite.Failed++
+		case "skipped", "pending", "other":
+			testCase.Skipped = &Result{Message: test.Message}
+			testCase.Outcomes.Skipped = testCase.Samples
+			suite.Skipped++
+		default:
+			testCase.Outcomes.Passed = testCase.Samples
+		}
+		// Reduced cases count every run as their status, unless the report
+		// kept their outcomes.
+		if test.Extra != nil && test.Extra.Outcomes != nil {
+			testCase.Outcomes = *test.Extra.Outcomes
+		}
+		suite.Tests++
+		suite.Time += testCase.Time
+		suite.Samples = max(suite.Samples, testCase.Samples)
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	testSuites := make([]TestSuite, 0, len(order))
+	for _, suiteName := range order {
+		testSuites = append(testSuites, *suites[suiteName])
+	}
+	return testSuites
+}
+
+// ToCTRF converts suites to a CTRF report. Reduced cases failed when every
+// run that passed or failed failed, were skipped when every run was skipped,
+// and are flaky when their runs both passed and failed.
+func ToCTRF(testSuites []TestSuite) CTRFReport {
+	report := CTRFReport{
+		ReportFormat: "CTRF",
+		SpecVersion:  "0.0.0",
+		Results: CTRFResults{
+			Tool:  CTRFTool{Name: "junit-reducer"},
+			Tests: []CTRFTest{},
+		},
+	}
+	summary := &report.Results.Summary
+
+	for _, testSuite := range sortedSuites(testSuites) {
+		suite, _ := json.Marshal(testSuite.Name)
+		for _, testCase := range sortedCases(testSuite.TestCases) {
+			file := testCase.File
+			if file == "" {
+				file = testSuite.File
+			}
+			test := CTRFTest{
+				Name:     testCase.Name,
+				Duration: math.Round(testCase.Time*1e6) / 1e3,
+				Suite:    suite,
+				FilePath: file,
+				Line:     testCase.Line,
+			}
+			outcomes := CaseOutcomes(testCase)
+			if testCase.Samples > 0 {
+				test.Extra = &CTRFExtra{Samples: testCase.Samples, Outcomes: &outcomes}
+			}
+
+			failed := outcomes.Failed + outcomes.Errored
+			switch {
+			case failed > 0 && outcomes.Passed == 0:
+				test.Status = "failed"
+				summary.Failed++
+				if result := firstResult(testCase.Failure, testCase.Error); result != nil {
+					test.Message = result.Message
+					test.Trace = result.Text
+				}
+			case outcomes.Passed == 0 && outcomes.Skipped > 0:
+				test.Status = "skipped"
+				summary.Skipped++
+				if testCase.Skipped != nil {
+					test.Message = testCase.Skipped.Message
+				}
+			default:
+				test.Status = "passed"
+				test.Flaky = failed > 0
+				summary.Passed++
+			}
+			summary.Tests++
+			report.Results.Tests = append(report.Results.Tests, test)
+		}
+	}
+	return report
+}
+
+func firstResult(results ...*Result) *Result {
+	for _, result := range results {
+		if result != nil {
+			return result
+		}
+	}
+	return nil
+}
+
+// DeserializeCTRFFromReader reads one or more concatenated CTRF reports.
+func DeserializeCTRFFromReader(testSuites []TestSuite, reader io.Reader, fileName string) ([]TestSuite, error) {
+	decoder := json.NewDecoder(reader)
+	for {
+		var report CTRFReport
+		err := decoder.Decode(&report)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			helpers.FatalMsg("failed to parse ctrf report: %v\n", err)
+			return nil, err
+		}
+		testSuites = append(testSuites, FromCTRF(report, fileName)...)
+	}
+	return testSuites, nil
+}
+
+// SerializeCTRF writes a CTRF report per report file name, alongside where
+// the XML report would be written, with a .json extension.
+func SerializeCTRF(outputPath string, testSuites []TestSuite) error {
+	return serializeFiles(outputPath, testSuites, ".json", writeCTRF)
+}
+
+// SerializeCTRFToWriter writes every suite into a single CTRF report.
+func SerializeCTRFToWriter(writer io.Writer, testSuites []TestSuite) error {
+	helpers.PrintMsg("serializing ctrf report to stdout\n")
+	err := writeCTRF(writer, testSuites)
+	if err != nil {
+		helpers.FatalMsg("failed to write ctrf report: %v\n", err)
+	}
+	return err
+}
+
+func writeCTRF(writer io.Writer, testSuites []TestSuite) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(ToCTRF(testSuites))
+}
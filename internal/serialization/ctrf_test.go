@@ -0,0 +1,82 @@
+package serialization
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const ctrfReport = `{
+  "reportFormat": "CTRF",
+  "specVersion": "0.0.0",
+  "results": {
+    "tool": {"name": "playwright"},
+    "summary": {"tests": 3, "passed": 1, "failed": 1, "pending": 0, "skipped": 1, "other": 0, "start": 1709287200000, "stop": 1709287210000},
+    "tests": [
+      {"name": "logs in", "status": "passed", "duration": 1500, "suite": "Login", "filePath": "e2e/login.spec.ts", "line": 4},
+      {"name": "logs out", "status": "failed", "duration": 250, "suite": "Login", "filePath": "e2e/login.spec.ts", "message": "timed out", "trace": "at login.spec.ts:9"},
+      {"name": "pays", "status": "skipped", "duration": 0, "suite": ["Checkout", "Cards"], "filePath": "e2e/checkout.spec.ts"}
+    ]
+  }
+}`
+
+func TestDeserializeCTRFFromReader(t *testing.T) {
+	testSuites, err := DeserializeCTRFFromReader(nil, strings.NewReader(ctrfReport), "ctrf.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(testSuites) != 2 {
+		t.Fatalf("Expected a suite per CTRF suite, but got %d", len(testSuites))
+	}
+	login := testSuites[0]
+	if login.Name != "Login" || login.File != "e2e/login.spec.ts" || login.Time != 1.75 || login.Tests != 2 || login.Failed != 1 || login.Timestamp != "2024-03-01T10:00:00" {
+		t.Errorf("Expected the login suite, but got %+v", login)
+	}
+	if failed := login.TestCases[1]; failed.Failure == nil || failed.Failure.Message != "timed out" {
+		t.Errorf("Expected the failed case, but got %+v", failed)
+	}
+	if checkout := testSuites[1]; checkout.Name != "Checkout > Cards" || checkout.Skipped != 1 {
+		t.Errorf("Expected nested suites to be joined, but got %+v", checkout)
+	}
+}
+
+func TestCTRFRoundTrip(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := SerializeCTRFToWriter(&buffer, reducedSuites); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{`"reportFormat": "CTRF"`, `"name": "junit-reducer"`, `"duration": 3000`, `"flaky": true`, `"samples": 4`} {
+		if !strings.Contains(buffer.String(), expected) {
+			t.Errorf("Expected CTRF to contain %s, but got %s", expected, buffer.String())
+		}
+	}
+
+	testSuites, err := DeserializeCTRFFromReader(nil, &buffer, "ctrf.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(testSuites) != 1 || testSuites[0].Time != 4.5 || testSuites[0].Samples != 4 || testSuites[0].TestCases[1].Samples != 2 {
+		t.Errorf("Expected the reduced suite to be read back, but got %+v", testSuites)
+	}
+	if outcomes := testSuites[0].TestCases[0].Outcomes; outcomes != (Outcomes{Passed: 3, Failed: 1}) {
+		t.Errorf("Expected the outcomes of the reduced case to be read back, but got %+v", outcomes)
+	}
+}
+
+func TestFromCTRFDerivesOutcomesFromStatus(t *testing.T) {
+	report := CTRFReport{Results: CTRFResults{Tests: []CTRFTest{
+		{Name: "test_create", Status: "failed", Extra: &CTRFExtra{Samples: 3}},
+		{Name: "test_update", Status: "passed", Extra: &CTRFExtra{Samples: 2}},
+		{Name: "test_destroy", Status: "failed"},
+	}}}
+
+	testCases := FromCTRF(report, "ctrf.xml")[0].TestCases
+	expected := []Outcomes{{Failed: 3}, {Passed: 2}, {Failed: 1}}
+	for i, testCase := range testCases {
+		if outcomes := CaseOutcomes(testCase); outcomes != expected[i] {
+			t.Errorf("Expected case '%s' to have outcomes %+v, but got %+v", testCase.Name, expected[i], outcomes)
+		}
+	}
+}
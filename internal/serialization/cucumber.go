@@ -0,0 +1,127 @@
+package serialization
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+)
+
+// cucumberFeature is a feature of a Cucumber JSON report.
+type cucumberFeature struct {
+	URI      string            `json:"uri"`
+	Name     string            `json:"name"`
+	Elements []cucumberElement `json:"elements"`
+}
+
+// cucumberElement is a scenario, or a background run before the scenario
+// that follows it.
+type cucumberElement struct {
+	Name   string         `json:"name"`
+	Type   string         `json:"type"`
+	Line   int            `json:"line"`
+	Before []cucumberStep `json:"before"`
+	Steps  []cucumberStep `json:"steps"`
+	After  []cucumberStep `json:"after"`
+}
+
+// cucumberStep is a step or a hook, with its duration in nanoseconds.
+type cucumberStep struct {
+	Result struct {
+		Status       string  `json:"status"`
+		Duration     float64 `json:"duration"`
+		ErrorMessage string  `json:"error_message"`
+	} `json:"result"`
+}
+
+// cucumberRun sums the steps and hooks of a scenario and its background.
+type cucumberRun struct {
+	time    float64
+	status  string
+	message string
+}
+
+// cucumberStatusRanks decide the status of a scenario from its steps.
+// Failures outrank ambiguous steps, which outrank steps that didn't run, which
+// outrank passed steps.
+var cucumberStatusRanks = map[string]int{"failed": 3, "ambiguous": 2, "skipped": 1, "pending": 1, "undefined": 1}
+
+func (run *cucumberRun) add(step cucumberStep) {
+	run.time += step.Result.Duration / 1e9
+	if cucumberStatusRanks[step.Result.Status] > cucumberStatusRanks[run.status] {
+		run.status = step.Result.Status
+		run.message = step.Result.ErrorMessage
+	}
+}
+
+func (run *cucumberRun) addElement(element cucumberElement) {
+	for _, steps := range [][]cucumberStep{element.Before, element.Steps, element.After} {
+		for _, step := range steps {
+			run.add(step)
+		}
+	}
+}
+
+// DeserializeCucumberFromReader reads one or more concatenated Cucumber JSON
+// reports, with a suite per feature and a case per scenario. Backgrounds are
+// counted towards the scenario that follows them.
+func DeserializeCucumberFromReader(testSuites []TestSuite, reader io.Reader, fileName string) ([]TestSuite, error) {
+	decoder := json.NewDecoder(reader)
+	for {
+		var features []cucumberFeature
+		err := decoder.Decode(&features)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			helpers.FatalMsg("failed to parse cucumber json: %v\n", err)
+			return nil, err
+		}
+
+		for _, feature := range features {
+			testSuites = append(testSuites, cucumberSuite(feature, fileName))
+		}
+	}
+	return testSuites, nil
+}
+
+func cucumberSuite(feature cucumberFeature, fileName string) TestSuite {
+	suite := TestSuite{Name: feature.Name, File: feature.URI, FileName: fileName}
+
+	var run cucumberRun
+	for _, element := range feature.Elements {
+		run.addElement(element)
+		if element.Type == "background" {
+			continue
+		}
+
+		testCase := TestCase{
+			Name:      element.Name,
+			Classname: feature.Name,
+			File:      feature.URI,
+			Line:      element.Line,
+			Time:      run.time,
+		}
+		switch run.status {
+		case "failed":
+			testCase.Failure = &Result{Message: firstLine(run.message), Text: run.message}
+			suite.Failed++
+		case "ambiguous":
+			testCase.Error = &Result{Message: firstLine(run.message), Type: run.status, Text: run.message}
+			suite.Errors++
+		case "skipped", "pending", "undefined":
+			testCase.Skipped = &Result{Message: run.status}
+			suite.Skipped++
+		}
+		suite.Tests++
+		suite.Time += run.time
+		suite.TestCases = append(suite.TestCases, testCase)
+		run = cucumberRun{}
+	}
+	return suite
+}
+
+func firstLine(text string) string {
+	return strings.SplitN(text, "\n", 2)[0]
+}
@@ -0,0 +1,57 @@
+package serialization
+
+import (
+	"strings"
+	"testing"
+)
+
+const cucumberReport = `[
+  {
+    "uri": "features/login.feature",
+    "name": "Login",
+    "elements": [
+      {"type": "background", "name": "", "line": 3, "steps": [
+        {"result": {"status": "passed", "duration": 500000000}}
+      ]},
+      {"type": "scenario", "name": "Valid login", "line": 6,
+        "before": [{"result": {"status": "passed", "duration": 250000000}}],
+        "steps": [
+          {"result": {"status": "passed", "duration": 1000000000}},
+          {"result": {"status": "failed", "duration": 250000000, "error_message": "expected dashboard\n./features/step_definitions/login.rb:4"}},
+          {"result": {"status": "skipped"}}
+        ]},
+      {"type": "background", "name": "", "line": 3, "steps": [
+        {"result": {"status": "passed", "duration": 500000000}}
+      ]},
+      {"type": "scenario", "name": "Remembered login", "line": 12, "steps": [
+        {"result": {"status": "undefined"}}
+      ]}
+    ]
+  }
+]`
+
+func TestDeserializeCucumberFromReader(t *testing.T) {
+	testSuites, err := DeserializeCucumberFromReader(nil, strings.NewReader(cucumberReport), "cucumber.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(testSuites) != 1 {
+		t.Fatalf("Expected a suite per feature, but got %d", len(testSuites))
+	}
+	suite := testSuites[0]
+	if suite.Name != "Login" || suite.File != "features/login.feature" || suite.Tests != 2 || suite.Failed != 1 || suite.Skipped != 1 || suite.Time != 2.5 {
+		t.Errorf("Expected the login feature, but got %+v", suite)
+	}
+
+	valid := suite.TestCases[0]
+	if valid.Name != "Valid login" || valid.Line != 6 || valid.Time != 2 {
+		t.Errorf("Expected the background and hook to count towards the scenario, but got %+v", valid)
+	}
+	if valid.Failure == nil || valid.Failure.Message != "expected dashboard" {
+		t.Errorf("Expected the failed step's message, but got %+v", valid.Failure)
+	}
+	if remembered := suite.TestCases[1]; remembered.Time != 0.5 || remembered.Skipped == nil {
+		t.Errorf("Expected the scenario with an undefined step to be skipped, but got %+v", remembered)
+	}
+}
@@ -0,0 +1,129 @@
+package serialization
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+)
+
+// DetectInputFormat tells the format of a report from its file extension or
+// its content. Anything that isn't recognized is read as JUnit XML.
+func DetectInputFormat(fileName string, data []byte) enums.InputFormat {
+	if strings.EqualFold(filepath.Ext(fileName), ".trx") {
+		return enums.InputFormatTRX
+	}
+
+	data = bytes.TrimLeft(data, "\ufeff \t\r\n")
+	if len(data) > 0 && data[0] == '<' {
+		return xmlInputFormat(data)
+	}
+	if len(data) > 0 && data[0] == '[' {
+		return enums.InputFormatCucumber
+	}
+	if isGoTestStream(data) {
+		return enums.InputFormatGoTest
+	}
+	if len(data) == 0 || data[0] != '{' {
+		return enums.InputFormatJUnit
+	}
+
+	var document map[string]json.RawMessage
+	if json.NewDecoder(bytes.NewReader(data)).Decode(&document) == nil {
+		if _, ok := document["results"]; ok {
+			return enums.InputFormatCTRF
+		}
+	}
+	return enums.InputFormatJSON
+}
+
+// isGoTestStream tells whether data is a `go test -json` stream, where every
+// line is an event with an action, while JSON and CTRF reports are a single
+// indented document. Lines before the first event that aren't JSON, like the
+// "# package" headers of build output, are skipped.
+func isGoTestStream(data []byte) bool {
+	for len(data) > 0 {
+		line := data
+		if index := bytes.IndexByte(data, '\n'); index >= 0 {
+			line, data = data[:index], data[index+1:]
+		} else {
+			data = nil
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var event map[string]json.RawMessage
+		if json.Unmarshal(line, &event) != nil {
+			return false
+		}
+		_, ok := event["Action"]
+		return ok
+	}
+	return false
+}
+
+// xmlInputFormat tells the format of an XML report from its root element.
+func xmlInputFormat(data []byte) enums.InputFormat {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return enums.InputFormatJUnit
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			switch start.Name.Local {
+			case "TestRun":
+				return enums.InputFormatTRX
+			case "test-run":
+				return enums.InputFormatNUnit
+			case "assemblies", "assembly":
+				return enums.InputFormatXUnit
+			}
+			return enums.InputFormatJUnit
+		}
+	}
+}
+
+// junitFileName is the report file name given to suites read from other
+// formats, as reduced suites are written back as JUnit XML.
+func junitFileName(fileName string) string {
+	return strings.TrimSuffix(fileName, filepath.Ext(fileName)) + ".xml"
+}
+
+// deserializeData reads a report in the given format, detecting it when it's
+// auto. JUnit XML is read with readJUnit.
+func deserializeData(
+	testSuites []TestSuite,
+	data []byte,
+	fileName string,
+	format enums.InputFormat,
+	readJUnit func([]TestSuite, io.Reader, string) ([]TestSuite, error),
+) ([]TestSuite, error) {
+	if format == enums.InputFormatAuto {
+		format = DetectInputFormat(fileName, data)
+	}
+
+	switch format {
+	case enums.InputFormatJSON:
+		return DeserializeJSONFromReader(testSuites, bytes.NewReader(data), fileName)
+	case enums.InputFormatGoTest:
+		return DeserializeGoTestFromReader(testSuites, bytes.NewReader(data), junitFileName(fileName))
+	case enums.InputFormatTRX:
+		return DeserializeTRXFromReader(testSuites, bytes.NewReader(data), junitFileName(fileName))
+	case enums.InputFormatNUnit:
+		return DeserializeNUnitFromReader(testSuites, bytes.NewReader(data), fileName)
+	case enums.InputFormatXUnit:
+		return DeserializeXUnitFromReader(testSuites, bytes.NewReader(data), fileName)
+	case enums.InputFormatCucumber:
+		return DeserializeCucumberFromReader(testSuites, bytes.NewReader(data), junitFileName(fileName))
+	case enums.InputFormatCTRF:
+		return DeserializeCTRFFromReader(testSuites, bytes.NewReader(data), junitFileName(fileName))
+	}
+	return readJUnit(testSuites, bytes.NewReader(data), fileName)
+}
@@ -0,0 +1,38 @@
+package serialization
+
+import (
+	"testing"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+)
+
+func TestDetectInputFormat(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected enums.InputFormat
+	}{
+		{`<?xml version="1.0"?><testsuites/>`, enums.InputFormatJUnit},
+		{"\n  <testsuite name=\"UserTest\"/>", enums.InputFormatJUnit},
+		{"{\n  \"version\": 1,\n  \"suites\": []\n}", enums.InputFormatJSON},
+		{`{"Action":"start","Package":"example.com/user"}` + "\n", enums.InputFormatGoTest},
+		{"# example.com/user\nuser_test.go:4:2: declared and not used: x\n" + `{"Action":"start","Package":"example.com/user"}` + "\n", enums.InputFormatGoTest},
+		{"ok  \texample.com/user\t0.01s\n", enums.InputFormatJUnit},
+		{`<?xml version="1.0"?><TestRun xmlns="http://microsoft.com/schemas/VisualStudio/TeamTest/2010"/>`, enums.InputFormatTRX},
+		{`<test-run id="2" testcasecount="3"><test-suite type="Assembly"/></test-run>`, enums.InputFormatNUnit},
+		{`<assemblies><assembly name="Users.Tests.dll"/></assemblies>`, enums.InputFormatXUnit},
+		{`[{"uri": "features/login.feature", "elements": []}]`, enums.InputFormatCucumber},
+		{"{\n  \"reportFormat\": \"CTRF\",\n  \"results\": {\"tests\": []}\n}", enums.InputFormatCTRF},
+		{"", enums.InputFormatJUnit},
+	}
+	for _, test := range tests {
+		if actual := DetectInputFormat("report.xml", []byte(test.data)); actual != test.expected {
+			t.Errorf("Expected %q to be %s, but got %s", test.data, enums.InputFormatKeys[test.expected], enums.InputFormatKeys[actual])
+		}
+	}
+}
+
+func TestDetectInputFormatByExtension(t *testing.T) {
+	if actual := DetectInputFormat("results/run.TRX", nil); actual != enums.InputFormatTRX {
+		t.Errorf("Expected a .trx file to be trx, but got %s", enums.InputFormatKeys[actual])
+	}
+}
@@ -0,0 +1,159 @@
+package serialization
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+)
+
+// goTestEvent is an event of the `go test -json` stream, as written by
+// test2json.
+type goTestEvent struct {
+	Time    time.Time
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// goTestPackage collects the cases of a package until its run ends.
+type goTestPackage struct {
+	suite  TestSuite
+	cases  map[string]int
+	output map[string]*strings.Builder
+}
+
+// testCase returns the case of the current run of a test, starting one when
+// the test hasn't run yet.
+func (pkg *goTestPackage) testCase(name string) *TestCase {
+	index, ok := pkg.cases[name]
+	if !ok {
+		return pkg.startCase(name)
+	}
+	return &pkg.suite.TestCases[index]
+}
+
+// startCase adds a case for a new run of a test. Tests run more than once,
+// like with -count, get a case per run, which are reduced together.
+func (pkg *goTestPackage) startCase(name string) *TestCase {
+	pkg.cases[name] = len(pkg.suite.TestCases)
+	pkg.suite.TestCases = append(pkg.suite.TestCases, TestCase{Name: name, Classname: pkg.suite.Name})
+	pkg.output[name] = &strings.Builder{}
+	return &pkg.suite.TestCases[pkg.cases[name]]
+}
+
+// finish counts the package's cases. Packages that didn't report an elapsed
+// time, such as interrupted runs, take the time of their top-level tests.
+func (pkg *goTestPackage) finish() TestSuite {
+	suite := pkg.suite
+	suite.Tests = len(suite.TestCases)
+	topLevelTime := 0.0
+	for _, testCase := range suite.TestCases {
+		if testCase.Failure != nil {
+			suite.Failed++
+		}
+		if testCase.Skipped != nil {
+			suite.Skipped++
+		}
+		if !strings.Contains(testCase.Name, "/") {
+			topLevelTime += testCase.Time
+		}
+	}
+	if suite.Time == 0 {
+		suite.Time = topLevelTime
+	}
+	return suite
+}
+
+// DeserializeGoTestFromReader reads a `go test -json` event stream, with a
+// suite per package run and a case per test run, including subtests. Packages
+// without tests are left out, as are lines that aren't events, like build
+// output.
+func DeserializeGoTestFromReader(testSuites []TestSuite, reader io.Reader, fileName string) ([]TestSuite, error) {
+	packages := make(map[string]*goTestPackage)
+	var order []string
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var event goTestEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			helpers.FatalMsg("failed to parse go test event: %v\n", err)
+			return nil, err
+		}
+		if event.Package == "" {
+			continue
+		}
+
+		pkg, ok := packages[event.Package]
+		if !ok {
+			pkg = &goTestPackage{
+				suite:  TestSuite{Name: event.Package, FileName: fileName},
+				cases:  make(map[string]int),
+				output: make(map[string]*strings.Builder),
+			}
+			if !event.Time.IsZero() {
+				pkg.suite.Timestamp = event.Time.UTC().Format("2006-01-02T15:04:05")
+			}
+			packages[event.Package] = pkg
+			order = append(order, event.Package)
+		}
+
+		if event.Test == "" {
+			if event.Action == "pass" || event.Action == "fail" || event.Action == "skip" {
+				pkg.suite.Time = event.Elapsed
+				testSuites = appendGoTestPackage(testSuites, pkg)
+				delete(packages, event.Package)
+			}
+			continue
+		}
+
+		if event.Action == "run" {
+			pkg.startCase(event.Test)
+			continue
+		}
+
+		testCase := pkg.testCase(event.Test)
+		switch event.Action {
+		case "output":
+			pkg.output[event.Test].WriteString(event.Output)
+		case "pass":
+			testCase.Time = event.Elapsed
+		case "fail":
+			testCase.Time = event.Elapsed
+			testCase.Failure = &Result{Message: "Failed", Text: pkg.output[event.Test].String()}
+		case "skip":
+			testCase.Time = event.Elapsed
+			testCase.Skipped = &Result{Message: "Skipped", Text: pkg.output[event.Test].String()}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		helpers.FatalMsg("failed to read go test events: %v\n", err)
+		return nil, err
+	}
+
+	for _, name := range order {
+		if pkg, ok := packages[name]; ok {
+			testSuites = appendGoTestPackage(testSuites, pkg)
+			delete(packages, name)
+		}
+	}
+	return testSuites, nil
+}
+
+func appendGoTestPackage(testSuites []TestSuite, pkg *goTestPackage) []TestSuite {
+	if len(pkg.suite.TestCases) == 0 {
+		return testSuites
+	}
+	return append(testSuites, pkg.finish())
+}
@@ -0,0 +1,61 @@
+package serialization
+
+import (
+	"strings"
+	"testing"
+)
+
+const goTestEvents = `{"Time":"2024-03-01T10:00:00Z","Action":"start","Package":"example.com/user"}
+{"Time":"2024-03-01T10:00:00Z","Action":"run","Package":"example.com/user","Test":"TestCreate"}
+{"Time":"2024-03-01T10:00:00Z","Action":"output","Package":"example.com/user","Test":"TestCreate","Output":"=== RUN   TestCreate\n"}
+{"Time":"2024-03-01T10:00:01Z","Action":"pass","Package":"example.com/user","Test":"TestCreate","Elapsed":1.25}
+{"Time":"2024-03-01T10:00:01Z","Action":"run","Package":"example.com/user","Test":"TestUpdate"}
+{"Time":"2024-03-01T10:00:01Z","Action":"run","Package":"example.com/user","Test":"TestUpdate/admin"}
+{"Time":"2024-03-01T10:00:01Z","Action":"output","Package":"example.com/user","Test":"TestUpdate/admin","Output":"    user_test.go:42: expected admin\n"}
+{"Time":"2024-03-01T10:00:02Z","Action":"fail","Package":"example.com/user","Test":"TestUpdate/admin","Elapsed":0.5}
+{"Time":"2024-03-01T10:00:02Z","Action":"fail","Package":"example.com/user","Test":"TestUpdate","Elapsed":0.75}
+{"Time":"2024-03-01T10:00:02Z","Action":"run","Package":"example.com/user","Test":"TestDestroy"}
+{"Time":"2024-03-01T10:00:02Z","Action":"skip","Package":"example.com/user","Test":"TestDestroy","Elapsed":0}
+{"Time":"2024-03-01T10:00:02Z","Action":"fail","Package":"example.com/user","Elapsed":2.5}
+# example.com/broken
+{"Time":"2024-03-01T10:00:02Z","Action":"start","Package":"example.com/empty"}
+{"Time":"2024-03-01T10:00:02Z","Action":"skip","Package":"example.com/empty","Elapsed":0}
+`
+
+func TestDeserializeGoTestFromReader(t *testing.T) {
+	testSuites, err := DeserializeGoTestFromReader(nil, strings.NewReader(goTestEvents), "go-test.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(testSuites) != 1 {
+		t.Fatalf("Expected 1 suite for the package with tests, but got %d", len(testSuites))
+	}
+	suite := testSuites[0]
+	if suite.Name != "example.com/user" || suite.Time != 2.5 || suite.Timestamp != "2024-03-01T10:00:00" || suite.FileName != "go-test.xml" {
+		t.Errorf("Expected the package suite, but got %+v", suite)
+	}
+	if suite.Tests != 4 || suite.Failed != 2 || suite.Skipped != 1 {
+		t.Errorf("Expected 4 tests with 2 failed and 1 skipped, but got %d, %d and %d", suite.Tests, suite.Failed, suite.Skipped)
+	}
+
+	subtest := suite.TestCases[2]
+	if subtest.Name != "TestUpdate/admin" || subtest.Classname != "example.com/user" || subtest.Time != 0.5 {
+		t.Errorf("Expected the subtest case, but got %+v", subtest)
+	}
+	if subtest.Failure == nil || !strings.Contains(subtest.Failure.Text, "expected admin") {
+		t.Errorf("Expected the subtest failure to hold its output, but got %+v", subtest.Failure)
+	}
+}
+
+func TestDeserializeGoTestInterruptedRun(t *testing.T) {
+	events := strings.Join(strings.Split(goTestEvents, "\n")[:9], "\n")
+	testSuites, err := DeserializeGoTestFromReader(nil, strings.NewReader(events), "go-test.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(testSuites) != 1 || testSuites[0].Time != 2 {
+		t.Errorf("Expected the time of the top-level tests, but got %+v", testSuites)
+	}
+}
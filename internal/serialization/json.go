@@ -0,0 +1,218 @@
+package serialization
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+)
+
+// JSONVersion is the version of the JSON report schema.
+const JSONVersion = 1
+
+// JSONReport is the JSON form of a set of reduced suites. Suites carry the
+// key they were reduced by, and Report is the name of the report file they
+// were read from, so reading the JSON back writes the same report files.
+type JSONReport struct {
+	Version int         `json:"version"`
+	Suites  []JSONSuite `json:"suites"`
+}
+
+type JSONSuite struct {
+	Key        string     `json:"key,omitempty"`
+	Name       string     `json:"name"`
+	File       string     `json:"file,omitempty"`
+	Report     string     `json:"report,omitempty"`
+	Timestamp  string     `json:"timestamp,omitempty"`
+	Time       float64    `json:"time"`
+	Tests      int        `json:"tests"`
+	Failed     int        `json:"failed"`
+	Errors     int        `json:"errors"`
+	Skipped    int        `json:"skipped"`
+	Assertions int        `json:"assertions"`
+	Samples    int        `json:"samples,omitempty"`
+	Cases      []JSONCase `json:"cases"`
+}
+
+type JSONCase struct {
+	Name       string    `json:"name"`
+	Classname  string    `json:"classname,omitempty"`
+	File       string    `json:"file,omitempty"`
+	Line       int       `json:"line,omitempty"`
+	Assertions int       `json:"assertions,omitempty"`
+	Time       float64   `json:"time"`
+	Samples    int       `json:"samples,omitempty"`
+	Outcomes   *Outcomes `json:"outcomes,omitempty"`
+}
+
+// ToJSON converts suites to the JSON report schema, keying each suite with
+// keyOf.
+func ToJSON(testSuites []TestSuite, keyOf func(TestSuite) string) JSONReport {
+	report := JSONReport{Version: JSONVersion, Suites: make([]JSONSuite, 0, len(testSuites))}
+	for _, testSuite := range testSuites {
+		suite := JSONSuite{
+			Key:        keyOf(testSuite),
+			Name:       testSuite.Name,
+			File:       testSuite.File,
+			Report:     testSuite.FileName,
+			Timestamp:  testSuite.Timestamp,
+			Time:       testSuite.Time,
+			Tests:      testSuite.Tests,
+			Failed:     testSuite.Failed,
+			Errors:     testSuite.Errors,
+			Skipped:    testSuite.Skipped,
+			Assertions: testSuite.Assertions,
+			Samples:    testSuite.Samples,
+			Cases:      make([]JSONCase, 0, len(testSuite.TestCases)),
+		}
+		for _, testCase := range testSuite.TestCases {
+			jsonCase := JSONCase{
+				Name:       testCase.Name,
+				Classname:  testCase.Classname,
+				File:       testCase.File,
+				Line:       testCase.Line,
+				Assertions: testCase.Assertions,
+				Time:       testCase.Time,
+				Samples:    testCase.Samples,
+			}
+			if testCase.Outcomes != (Outcomes{}) {
+				outcomes := testCase.Outcomes
+				jsonCase.Outcomes = &outcomes
+			}
+			suite.Cases = append(suite.Cases, jsonCase)
+		}
+		report.Suites = append(report.Suites, suite)
+	}
+	return report
+}
+
+// FromJSON converts a JSON report back to suites. Suites without a report
+// name are attributed to fileName, with an .xml extension like other
+// formats.
+func FromJSON(report JSONReport, fileName string) []TestSuite {
+	testSuites := make([]TestSuite, 0, len(report.Suites))
+	for _, suite := range report.Suites {
+		testSuite := TestSuite{
+			Name:       suite.Name,
+			File:       suite.File,
+			FileName:   suite.Report,
+			Timestamp:  suite.Timestamp,
+			Time:       suite.Time,
+			Tests:      suite.Tests,
+			Failed:     suite.Failed,
+			Errors:     suite.Errors,
+			Skipped:    suite.Skipped,
+			Assertions: suite.Assertions,
+			Samples:    suite.Samples,
+		}
+		if testSuite.FileName == "" {
+			testSuite.FileName = junitFileName(fileName)
+		}
+		for _, jsonCase := range suite.Cases {
+			testCase := TestCase{
+				Name:       jsonCase.Name,
+				Classname:  jsonCase.Classname,
+				File:       jsonCase.File,
+				Line:       jsonCase.Line,
+				Assertions: jsonCase.Assertions,
+				Time:       jsonCase.Time,
+				Samples:    jsonCase.Samples,
+			}
+			if jsonCase.Outcomes != nil {
+				testCase.Outcomes = *jsonCase.Outcomes
+			}
+			testSuite.TestCases = append(testSuite.TestCases, testCase)
+		}
+		testSuites = append(testSuites, testSuite)
+	}
+	return testSuites
+}
+
+// DeserializeJSONFromReader reads one or more concatenated JSON reports.
+func DeserializeJSONFromReader(testSuites []TestSuite, reader io.Reader, fileName string) ([]TestSuite, error) {
+	decoder := json.NewDecoder(reader)
+	for {
+		var report JSONReport
+		err := decoder.Decode(&report)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			helpers.FatalMsg("failed to parse json report: %v\n", err)
+			return nil, err
+		}
+		testSuites = append(testSuites, FromJSON(report, fileName)...)
+	}
+	return testSuites, nil
+}
+
+// SerializeJSON writes a JSON report per report file name, alongside where
+// the XML report would be written, with a .json extension.
+func SerializeJSON(outputPath string, testSuites []TestSuite, keyOf func(TestSuite) string) error {
+	return serializeFiles(outputPath, testSuites, ".json", func(writer io.Writer, suites []TestSuite) error {
+		return writeJSON(writer, suites, keyOf)
+	})
+}
+
+// serializeFiles writes the suites of each report file name to a file of
+// that name with the extension replaced.
+func serializeFiles(outputPath string, testSuites []TestSuite, extension string, write func(io.Writer, []TestSuite) error) error {
+	testSuiteMap := make(map[string][]TestSuite)
+	for _, testSuite := range testSuites {
+		testSuiteMap[testSuite.FileName] = append(testSuiteMap[testSuite.FileName], testSuite)
+	}
+
+	for fileName, suites := range testSuiteMap {
+		outputFileName := filepath.Join(outputPath, strings.TrimSuffix(fileName, filepath.Ext(fileName))+extension)
+		helpers.PrintMsg("serializing report: %v\n", outputFileName)
+
+		file, err := os.Create(outputFileName)
+		if err != nil {
+			helpers.FatalMsg("failed to create report: %v\n", err)
+			return err
+		}
+		err = write(file, suites)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			helpers.FatalMsg("failed to write report: %v\n", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// SerializeJSONToWriter writes every suite into a single JSON report,
+// ordered like SerializeToWriter.
+func SerializeJSONToWriter(writer io.Writer, testSuites []TestSuite, keyOf func(TestSuite) string) error {
+	helpers.PrintMsg("serializing json report to stdout\n")
+	err := writeJSON(writer, testSuites, keyOf)
+	if err != nil {
+		helpers.FatalMsg("failed to write json report: %v\n", err)
+	}
+	return err
+}
+
+func writeJSON(writer io.Writer, testSuites []TestSuite, keyOf func(TestSuite) string) error {
+	suites := sortedSuites(testSuites)
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(ToJSON(suites, keyOf))
+}
+
+func sortedSuites(testSuites []TestSuite) []TestSuite {
+	suites := make([]TestSuite, len(testSuites))
+	copy(suites, testSuites)
+	sort.SliceStable(suites, func(i, j int) bool {
+		if suites[i].FileName != suites[j].FileName {
+			return suites[i].FileName < suites[j].FileName
+		}
+		return suites[i].Name < suites[j].Name
+	})
+	return suites
+}
@@ -0,0 +1,67 @@
+package serialization
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var reducedSuites = []TestSuite{
+	{
+		Name:       "UserTest",
+		File:       "test/user_test.rb",
+		FileName:   "run.xml",
+		Time:       4.5,
+		Tests:      2,
+		Failed:     1,
+		Assertions: 3,
+		Samples:    4,
+		TestCases: []TestCase{
+			{Name: "test_create", Classname: "UserTest", Line: 4, Time: 3, Samples: 4, Outcomes: Outcomes{Passed: 3, Failed: 1}},
+			{Name: "test_update", Classname: "UserTest", Line: 9, Time: 1.5, Samples: 2},
+		},
+	},
+}
+
+func suiteName(testSuite TestSuite) string {
+	return testSuite.Name
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := SerializeJSONToWriter(&buffer, reducedSuites, suiteName); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{`"version": 1`, `"key": "UserTest"`, `"report": "run.xml"`, `"samples": 4`} {
+		if !strings.Contains(buffer.String(), expected) {
+			t.Errorf("Expected JSON to contain %s, but got %s", expected, buffer.String())
+		}
+	}
+
+	testSuites, err := DeserializeJSONFromReader(nil, &buffer, "other.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(testSuites, reducedSuites) {
+		t.Errorf("Expected %+v, but got %+v", reducedSuites, testSuites)
+	}
+}
+
+func TestDeserializeConcatenatedJSON(t *testing.T) {
+	stream := `{"version": 1, "suites": [{"name": "UserTest", "time": 1, "cases": []}]}
+{"version": 1, "suites": [{"name": "AccountTest", "report": "accounts.xml", "time": 2, "cases": [{"name": "test_show", "time": 2}]}]}`
+
+	testSuites, err := DeserializeJSONFromReader(nil, strings.NewReader(stream), "stdin.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(testSuites) != 2 || testSuites[0].FileName != "stdin.xml" || testSuites[1].FileName != "accounts.xml" {
+		t.Errorf("Expected 2 suites attributed to their reports, but got %+v", testSuites)
+	}
+	if len(testSuites[1].TestCases) != 1 || testSuites[1].TestCases[0].Time != 2 {
+		t.Errorf("Expected the case to be read, but got %+v", testSuites[1].TestCases)
+	}
+}
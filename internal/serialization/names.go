@@ -0,0 +1,50 @@
+package serialization
+
+import (
+	"path"
+	"strings"
+	"unicode"
+)
+
+// SuiteID identifies a suite by its test file, falling back to its name.
+func SuiteID(testSuite TestSuite) string {
+	if testSuite.File != "" {
+		return testSuite.File
+	}
+	return testSuite.Name
+}
+
+// CaseID identifies a case as "file::name", falling back to
+// "classname::name" when the case doesn't record its file.
+func CaseID(testCase TestCase) string {
+	if testCase.File != "" {
+		return testCase.File + "::" + testCase.Name
+	}
+	return testCase.Classname + "::" + testCase.Name
+}
+
+// PytestNodeID builds the pytest node id of a case, "path::Class::name", from
+// the dotted classname pytest records. The file is derived from the module
+// part of the classname when the case doesn't record it.
+func PytestNodeID(file string, classname string, name string) string {
+	module := classname
+	class := ""
+	if index := strings.LastIndex(classname, "."); index >= 0 {
+		last := classname[index+1:]
+		if last != "" && unicode.IsUpper([]rune(last)[0]) {
+			module = classname[:index]
+			class = last
+		}
+	}
+
+	if file == "" && module != "" {
+		file = strings.ReplaceAll(module, ".", "/") + ".py"
+	}
+	file = strings.TrimPrefix(path.Clean(strings.ReplaceAll(file, "\\", "/")), "./")
+
+	nodeID := file
+	if class != "" {
+		nodeID += "::" + class
+	}
+	return nodeID + "::" + name
+}
@@ -0,0 +1,23 @@
+package serialization
+
+import "testing"
+
+func TestPytestNodeID(t *testing.T) {
+	tests := []struct {
+		file      string
+		classname string
+		name      string
+		expected  string
+	}{
+		{"tests/test_user.py", "tests.test_user.TestUser", "test_create", "tests/test_user.py::TestUser::test_create"},
+		{"", "tests.test_user.TestUser", "test_create", "tests/test_user.py::TestUser::test_create"},
+		{"", "tests.test_user", "test_create[admin]", "tests/test_user.py::test_create[admin]"},
+		{"./tests/test_user.py", "tests.test_user", "test_create", "tests/test_user.py::test_create"},
+	}
+
+	for _, test := range tests {
+		if actual := PytestNodeID(test.file, test.classname, test.name); actual != test.expected {
+			t.Errorf("Expected node id %s, but got %s", test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package serialization
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+)
+
+// nunitTestRun is an NUnit 3 result file, whose suites nest from assemblies
+// and namespaces down to fixtures.
+type nunitTestRun struct {
+	Suites []nunitSuite `xml:"test-suite"`
+}
+
+type nunitSuite struct {
+	Type      string       `xml:"type,attr"`
+	FullName  string       `xml:"fullname,attr"`
+	Duration  float64      `xml:"duration,attr"`
+	StartTime string       `xml:"start-time,attr"`
+	Asserts   int          `xml:"asserts,attr"`
+	Suites    []nunitSuite `xml:"test-suite"`
+	Cases     []nunitCase  `xml:"test-case"`
+}
+
+type nunitCase struct {
+	Name      string  `xml:"name,attr"`
+	ClassName string  `xml:"classname,attr"`
+	Result    string  `xml:"result,attr"`
+	Label     string  `xml:"label,attr"`
+	Duration  float64 `xml:"duration,attr"`
+	Asserts   int     `xml:"asserts,attr"`
+	Message   string  `xml:"failure>message"`
+	Trace     string  `xml:"failure>stack-trace"`
+	Reason    string  `xml:"reason>message"`
+}
+
+// DeserializeNUnitFromReader reads an NUnit 3 result file, with a suite per
+// test fixture and a case per test case, parameterized ones included.
+func DeserializeNUnitFromReader(testSuites []TestSuite, reader io.Reader, fileName string) ([]TestSuite, error) {
+	var testRun nunitTestRun
+	if err := xml.NewDecoder(reader).Decode(&testRun); err != nil {
+		helpers.FatalMsg("failed to parse nunit xml: %v\n", err)
+		return nil, err
+	}
+
+	for _, suite := range testRun.Suites {
+		testSuites = appendNUnitSuite(testSuites, suite, nil, fileName)
+	}
+	return testSuites, nil
+}
+
+// appendNUnitSuite walks nested suites, collecting cases into the fixture
+// they belong to. Cases outside of any fixture get a suite of their own.
+func appendNUnitSuite(testSuites []TestSuite, suite nunitSuite, fixture *TestSuite, fileName string) []TestSuite {
+	isFixture := suite.Type == "TestFixture" || (fixture == nil && len(suite.Cases) > 0)
+	if isFixture {
+		fixture = &TestSuite{
+			Name:       suite.FullName,
+			FileName:   fileName,
+			Timestamp:  nunitTimestamp(suite.StartTime),
+			Time:       suite.Duration,
+			Assertions: suite.Asserts,
+		}
+	}
+
+	for _, nunitCase := range suite.Cases {
+		testCase := TestCase{
+			Name:       nunitCase.Name,
+			Classname:  nunitCase.ClassName,
+			Time:       nunitCase.Duration,
+			Assertions: nunitCase.Asserts,
+		}
+		switch nunitCase.Result {
+		case "Failed":
+			if nunitCase.Label == "Error" {
+				testCase.Error = &Result{Message: nunitCase.Message, Text: nunitCase.Trace}
+				fixture.Errors++
+			} else {
+				testCase.Failure = &Result{Message: nunitCase.Message, Text: nunitCase.Trace}
+				fixture.Failed++
+			}
+		case "Skipped", "Inconclusive":
+			testCase.Skipped = &Result{Message: nunitCase.Reason}
+			fixture.Skipped++
+		}
+		fixture.Tests++
+		fixture.TestCases = append(fixture.TestCases, testCase)
+	}
+
+	for _, child := range suite.Suites {
+		testSuites = appendNUnitSuite(testSuites, child, fixture, fileName)
+	}
+
+	if isFixture && len(fixture.TestCases) > 0 {
+		testSuites = append(testSuites, *fixture)
+	}
+	return testSuites
+}
+
+func nunitTimestamp(startTime string) string {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05.999999999Z07:00"} {
+		if parsed, err := time.Parse(layout, startTime); err == nil {
+			return parsed.UTC().Format("2006-01-02T15:04:05")
+		}
+	}
+	return ""
+}
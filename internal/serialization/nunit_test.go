@@ -0,0 +1,50 @@
+package serialization
+
+import (
+	"strings"
+	"testing"
+)
+
+const nunitReport = `<?xml version="1.0" encoding="utf-8"?>
+<test-run id="2" testcasecount="4" result="Failed" duration="3.5">
+  <test-suite type="Assembly" name="Users.Tests.dll" fullname="bin/Users.Tests.dll" duration="3.5">
+    <test-suite type="TestSuite" name="Users" fullname="Users">
+      <test-suite type="TestFixture" name="UserTests" fullname="Users.UserTests" classname="Users.UserTests" duration="2.5" start-time="2024-03-01 10:00:00Z" asserts="3">
+        <test-case name="Creates" fullname="Users.UserTests.Creates" classname="Users.UserTests" result="Passed" duration="1" asserts="2" />
+        <test-case name="Updates" fullname="Users.UserTests.Updates" classname="Users.UserTests" result="Failed" label="Error" duration="0.5" asserts="1">
+          <failure><message>System.NullReferenceException</message><stack-trace>at Users.UserTests.Updates()</stack-trace></failure>
+        </test-case>
+        <test-suite type="ParameterizedMethod" name="Logs" fullname="Users.UserTests.Logs">
+          <test-case name="Logs(1)" classname="Users.UserTests" result="Failed" duration="0.25" />
+          <test-case name="Logs(2)" classname="Users.UserTests" result="Skipped" duration="0">
+            <reason><message>Not ready</message></reason>
+          </test-case>
+        </test-suite>
+      </test-suite>
+    </test-suite>
+  </test-suite>
+</test-run>`
+
+func TestDeserializeNUnitFromReader(t *testing.T) {
+	testSuites, err := DeserializeNUnitFromReader(nil, strings.NewReader(nunitReport), "nunit.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(testSuites) != 1 {
+		t.Fatalf("Expected a suite for the fixture, but got %d", len(testSuites))
+	}
+	suite := testSuites[0]
+	if suite.Name != "Users.UserTests" || suite.Time != 2.5 || suite.Timestamp != "2024-03-01T10:00:00" || suite.Assertions != 3 {
+		t.Errorf("Expected the fixture suite, but got %+v", suite)
+	}
+	if suite.Tests != 4 || suite.Failed != 1 || suite.Errors != 1 || suite.Skipped != 1 {
+		t.Errorf("Expected 4 tests with 1 failed, 1 errored and 1 skipped, but got %+v", suite)
+	}
+	if suite.TestCases[1].Error == nil || suite.TestCases[1].Error.Message != "System.NullReferenceException" {
+		t.Errorf("Expected the errored case, but got %+v", suite.TestCases[1])
+	}
+	if suite.TestCases[3].Skipped == nil || suite.TestCases[3].Skipped.Message != "Not ready" {
+		t.Errorf("Expected the skipped case, but got %+v", suite.TestCases[3])
+	}
+}
@@ -6,27 +6,41 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
 	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
 )
 
 type TestSuites struct {
+	// Totals across the suites, written when a document is merged
+	Time       float64     `xml:"time,attr,omitempty"`
+	Tests      int         `xml:"tests,attr,omitempty"`
+	Failed     int         `xml:"failed,attr,omitempty"`
+	Errors     int         `xml:"errors,attr,omitempty"`
+	Skipped    int         `xml:"skipped,attr,omitempty"`
+	Assertions int         `xml:"assertions,attr,omitempty"`
 	TestSuites []TestSuite `xml:"testsuite"`
 }
 
 type TestSuite struct {
-	Name string `xml:"name,attr"`
-	File string `xml:"filepath,attr"`
+	Name      string `xml:"name,attr"`
+	File      string `xml:"filepath,attr"`
+	Timestamp string `xml:"timestamp,attr,omitempty"`
 	// Aggregated fields
-	Time       float64    `xml:"time,attr"`
-	Tests      int        `xml:"tests,attr"`
-	Failed     int        `xml:"failed,attr"`
-	Errors     int        `xml:"errors,attr"`
-	Skipped    int        `xml:"skipped,attr"`
-	Assertions int        `xml:"assertions,attr"`
-	TestCases  []TestCase `xml:"testcase"`
+	Time       float64 `xml:"time,attr"`
+	Tests      int     `xml:"tests,attr"`
+	Failed     int     `xml:"failed,attr"`
+	Errors     int     `xml:"errors,attr"`
+	Skipped    int     `xml:"skipped,attr"`
+	Assertions int     `xml:"assertions,attr"`
+	// Number of runs a reduced suite was reduced from
+	Samples   int        `xml:"samples,attr,omitempty"`
+	TestCases []TestCase `xml:"testcase"`
 	// For reserialization
 	FileName string `xml:"-"`
+	// Modification time of the report file, for time-window filtering
+	ModTime time.Time `xml:"-"`
 }
 
 type TestCase struct {
@@ -37,6 +51,100 @@ type TestCase struct {
 	Assertions int    `xml:"assertions,attr"`
 	// Aggregated fields
 	Time float64 `xml:"time,attr"`
+	// Number of runs a reduced case was reduced from
+	Samples int `xml:"samples,attr,omitempty"`
+	// Outcome of a single run, cleared when the case is reduced
+	Failure    *Result    `xml:"failure,omitempty"`
+	Error      *Result    `xml:"error,omitempty"`
+	Skipped    *Result    `xml:"skipped,omitempty"`
+	Properties Properties `xml:"properties,omitempty"`
+	// Outcomes of the runs a reduced case was reduced from, written as
+	// attributes so reduced reports can be reduced again
+	Outcomes
+}
+
+type Result struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+type Property struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// Properties are written in a properties element, which is left out when
+// there are none.
+type Properties []Property
+
+type propertiesElement struct {
+	Properties []Property `xml:"property"`
+}
+
+func (properties Properties) MarshalXML(encoder *xml.Encoder, start xml.StartElement) error {
+	return encoder.EncodeElement(propertiesElement{Properties: properties}, start)
+}
+
+func (properties *Properties) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) error {
+	var element propertiesElement
+	if err := decoder.DecodeElement(&element, &start); err != nil {
+		return err
+	}
+	*properties = append(*properties, element.Properties...)
+	return nil
+}
+
+// Outcomes tallies how the runs of a case ended.
+type Outcomes struct {
+	Passed  int `json:"passed" xml:"passed,attr,omitempty"`
+	Failed  int `json:"failed" xml:"failed,attr,omitempty"`
+	Errored int `json:"errored" xml:"errored,attr,omitempty"`
+	Skipped int `json:"skipped" xml:"skipped,attr,omitempty"`
+}
+
+func (outcomes Outcomes) Add(other Outcomes) Outcomes {
+	return Outcomes{
+		Passed:  outcomes.Passed + other.Passed,
+		Failed:  outcomes.Failed + other.Failed,
+		Errored: outcomes.Errored + other.Errored,
+		Skipped: outcomes.Skipped + other.Skipped,
+	}
+}
+
+// CaseOutcomes returns the outcomes of the runs a case stands for. A case
+// from a single run is counted by its failure, error or skipped element.
+// Reduced cases only carry the outcomes tallied when they were reduced, as
+// their elements were cleared.
+func CaseOutcomes(testCase TestCase) Outcomes {
+	if testCase.Outcomes != (Outcomes{}) || testCase.Samples > 0 {
+		return testCase.Outcomes
+	}
+	if testCase.Error != nil {
+		return Outcomes{Errored: 1}
+	} else if testCase.Failure != nil {
+		return Outcomes{Failed: 1}
+	} else if testCase.Skipped != nil {
+		return Outcomes{Skipped: 1}
+	}
+	return Outcomes{Passed: 1}
+}
+
+// SuiteWeight is the number of runs a suite stands for: its recorded sample
+// count when it was already reduced, or a single run.
+func SuiteWeight(testSuite TestSuite) int {
+	if testSuite.Samples > 0 {
+		return testSuite.Samples
+	}
+	return 1
+}
+
+// CaseWeight is the number of runs a case stands for.
+func CaseWeight(testCase TestCase) int {
+	if testCase.Samples > 0 {
+		return testCase.Samples
+	}
+	return 1
 }
 
 func UnmarshalTestSuites(xmlData []byte, fileName string) (*TestSuites, error) {
@@ -70,8 +178,52 @@ func DeserializeFromReader(
 	return testSuites, nil
 }
 
+// DeserializeStream parses a concatenated stream of reports in the given
+// format, such as several JUnit XML reports piped through `cat`, attributing
+// every suite to fileName.
+func DeserializeStream(
+	testSuites []TestSuite,
+	reader io.Reader,
+	fileName string,
+	format enums.InputFormat,
+) ([]TestSuite, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		helpers.FatalMsg("failed to read report stream: %v\n", err)
+		return nil, err
+	}
+	return deserializeData(testSuites, data, fileName, format, deserializeJUnitStream)
+}
+
+func deserializeJUnitStream(
+	testSuites []TestSuite,
+	reader io.Reader,
+	fileName string,
+) ([]TestSuite, error) {
+	decoder := xml.NewDecoder(reader)
+	for {
+		var xmlTestSuites TestSuites
+		err := decoder.Decode(&xmlTestSuites)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			helpers.FatalMsg("failed to parse junit xml stream: %v\n", err)
+			return nil, err
+		}
+		for i := range xmlTestSuites.TestSuites {
+			xmlTestSuites.TestSuites[i].FileName = fileName
+		}
+		testSuites = append(testSuites, xmlTestSuites.TestSuites...)
+	}
+	return testSuites, nil
+}
+
+// Deserialize reads the report files in the given format, detecting the
+// format of each file when it's auto.
 func Deserialize(
 	junitFilePaths []string,
+	format enums.InputFormat,
 ) ([]TestSuite, error) {
 	var testSuites []TestSuite
 	for _, junitFilePath := range junitFilePaths {
@@ -84,12 +236,22 @@ func Deserialize(
 
 		helpers.PrintMsg("deserializing junit xml: %v\n", junitFilePath)
 
-		testSuites, err = DeserializeFromReader(testSuites, file, fileName)
+		firstSuite := len(testSuites)
+		data, err := io.ReadAll(file)
 		file.Close()
+		if err == nil {
+			testSuites, err = deserializeData(testSuites, data, fileName, format, DeserializeFromReader)
+		}
 		if err != nil {
 			helpers.FatalMsg("failed to deserialize junit xml: %v\n", err)
 			return nil, err
 		}
+
+		if info, err := os.Stat(junitFilePath); err == nil {
+			for i := firstSuite; i < len(testSuites); i++ {
+				testSuites[i].ModTime = info.ModTime()
+			}
+		}
 	}
 	return testSuites, nil
 }
@@ -103,30 +265,71 @@ func Serialize(outputPath string, testSuites []TestSuite) {
 	}
 
 	for fileName, suites := range testSuiteMap {
-		testSuitesWrapper := TestSuites{TestSuites: suites}
 		outputFileName := filepath.Join(outputPath, fileName)
 
-		// Marshal to XML
-		xmlBytes, err := xml.MarshalIndent(testSuitesWrapper, "", "  ")
+		xmlBytes, err := marshalTestSuites(suites)
 		if err != nil {
 			helpers.FatalMsg("failed to marshal junit xml: %v\n", err)
 		}
 
-		// Convert XML bytes to string for replacement
-		xmlString := string(xmlBytes)
-
-		// Marshalling XML requires the wrapper type be title-cased to be exportable
-		// in Go, but we want to preserve the original casing for the XML tags.
-		xmlString = strings.Replace(xmlString, "<TestSuites>", "<testsuites>", 1)
-		xmlString = strings.Replace(xmlString, "</TestSuites>", "</testsuites>", 1)
-		// Add XML header
-		xmlString = xml.Header + xmlString
-
 		// Write to file
-		xmlBytes = []byte(xmlString)
 		err = os.WriteFile(outputFileName, xmlBytes, 0644)
 		if err != nil {
 			helpers.FatalMsg("failed to write junit xml to file: %v\n", err)
 		}
 	}
 }
+
+// SerializeToWriter writes every suite into a single combined JUnit XML
+// document, ordered by report file name, and cases by classname and name, so
+// the output is stable.
+func SerializeToWriter(writer io.Writer, testSuites []TestSuite) error {
+	suites := sortedSuites(testSuites)
+	for i := range suites {
+		suites[i].TestCases = sortedCases(suites[i].TestCases)
+	}
+
+	helpers.PrintMsg("serializing junit xml to stdout\n")
+
+	return WriteDocument(writer, TestSuites{TestSuites: suites})
+}
+
+// WriteDocument writes a single JUnit XML document, including any root totals.
+func WriteDocument(writer io.Writer, document TestSuites) error {
+	xmlBytes, err := marshalDocument(document)
+	if err != nil {
+		helpers.FatalMsg("failed to marshal junit xml: %v\n", err)
+		return err
+	}
+
+	_, err = writer.Write(append(xmlBytes, '\n'))
+	if err != nil {
+		helpers.FatalMsg("failed to write junit xml: %v\n", err)
+		return err
+	}
+	return nil
+}
+
+func marshalTestSuites(suites []TestSuite) ([]byte, error) {
+	return marshalDocument(TestSuites{TestSuites: suites})
+}
+
+func marshalDocument(document TestSuites) ([]byte, error) {
+	// Marshal to XML
+	xmlBytes, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert XML bytes to string for replacement
+	xmlString := string(xmlBytes)
+
+	// Marshalling XML requires the wrapper type be title-cased to be exportable
+	// in Go, but we want to preserve the original casing for the XML tags.
+	xmlString = strings.Replace(xmlString, "<TestSuites", "<testsuites", 1)
+	xmlString = strings.Replace(xmlString, "</TestSuites>", "</testsuites>", 1)
+	// Add XML header
+	xmlString = xml.Header + xmlString
+
+	return []byte(xmlString), nil
+}
@@ -0,0 +1,31 @@
+package serialization
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSerializeToWriterSortsCases(t *testing.T) {
+	suites := []TestSuite{{
+		Name:     "UserTest",
+		FileName: "run.xml",
+		TestCases: []TestCase{
+			{Name: "test_update", Classname: "UserTest"},
+			{Name: "test_create", Classname: "UserTest"},
+		},
+	}}
+
+	var buffer bytes.Buffer
+	if err := SerializeToWriter(&buffer, suites); err != nil {
+		t.Fatal(err)
+	}
+
+	output := buffer.String()
+	if strings.Index(output, "test_create") > strings.Index(output, "test_update") {
+		t.Errorf("Expected cases ordered by name, but got %s", output)
+	}
+	if suites[0].TestCases[0].Name != "test_update" {
+		t.Errorf("Expected the suites passed in to be left unchanged")
+	}
+}
@@ -0,0 +1,125 @@
+package serialization
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+)
+
+var suiteColumns = []string{"key", "report", "file", "name", "time", "tests", "failed", "errors", "skipped", "assertions", "samples"}
+
+var caseColumns = []string{"key", "report", "file", "classname", "name", "line", "time", "assertions", "samples", "passed", "failed", "errored", "skipped"}
+
+// TableExtension is the file extension of a table written with comma.
+func TableExtension(comma rune) string {
+	if comma == '\t' {
+		return ".tsv"
+	}
+	return ".csv"
+}
+
+// SerializeTable writes a table per report file name, alongside where the
+// XML report would be written, with a .csv or .tsv extension.
+func SerializeTable(outputPath string, testSuites []TestSuite, keyOf func(TestSuite) string, rows enums.Granularity, comma rune) error {
+	return serializeFiles(outputPath, testSuites, TableExtension(comma), func(writer io.Writer, suites []TestSuite) error {
+		return WriteTable(writer, suites, keyOf, rows, comma)
+	})
+}
+
+// SerializeTableToWriter writes every suite into a single table, ordered like
+// SerializeToWriter.
+func SerializeTableToWriter(writer io.Writer, testSuites []TestSuite, keyOf func(TestSuite) string, rows enums.Granularity, comma rune) error {
+	helpers.PrintMsg("serializing table to stdout\n")
+	err := WriteTable(writer, testSuites, keyOf, rows, comma)
+	if err != nil {
+		helpers.FatalMsg("failed to write table: %v\n", err)
+	}
+	return err
+}
+
+// WriteTable writes a header and then a row per suite, or per case, with
+// fields separated by comma. Case rows carry the key of their suite.
+func WriteTable(writer io.Writer, testSuites []TestSuite, keyOf func(TestSuite) string, rows enums.Granularity, comma rune) error {
+	table := csv.NewWriter(writer)
+	table.Comma = comma
+
+	columns := suiteColumns
+	if rows == enums.GranularityCase {
+		columns = caseColumns
+	}
+	if err := table.Write(columns); err != nil {
+		return err
+	}
+
+	for _, testSuite := range sortedSuites(testSuites) {
+		key := keyOf(testSuite)
+		if rows != enums.GranularityCase {
+			err := table.Write([]string{
+				key,
+				testSuite.FileName,
+				testSuite.File,
+				testSuite.Name,
+				formatTime(testSuite.Time),
+				strconv.Itoa(testSuite.Tests),
+				strconv.Itoa(testSuite.Failed),
+				strconv.Itoa(testSuite.Errors),
+				strconv.Itoa(testSuite.Skipped),
+				strconv.Itoa(testSuite.Assertions),
+				strconv.Itoa(SuiteWeight(testSuite)),
+			})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, testCase := range sortedCases(testSuite.TestCases) {
+			file := testCase.File
+			if file == "" {
+				file = testSuite.File
+			}
+			outcomes := CaseOutcomes(testCase)
+			err := table.Write([]string{
+				key,
+				testSuite.FileName,
+				file,
+				testCase.Classname,
+				testCase.Name,
+				strconv.Itoa(testCase.Line),
+				formatTime(testCase.Time),
+				strconv.Itoa(testCase.Assertions),
+				strconv.Itoa(CaseWeight(testCase)),
+				strconv.Itoa(outcomes.Passed),
+				strconv.Itoa(outcomes.Failed),
+				strconv.Itoa(outcomes.Errored),
+				strconv.Itoa(outcomes.Skipped),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	table.Flush()
+	return table.Error()
+}
+
+func formatTime(time float64) string {
+	return strconv.FormatFloat(time, 'f', -1, 64)
+}
+
+func sortedCases(testCases []TestCase) []TestCase {
+	cases := make([]TestCase, len(testCases))
+	copy(cases, testCases)
+	sort.SliceStable(cases, func(i, j int) bool {
+		if cases[i].Classname != cases[j].Classname {
+			return cases[i].Classname < cases[j].Classname
+		}
+		return cases[i].Name < cases[j].Name
+	})
+	return cases
+}
@@ -0,0 +1,39 @@
+package serialization
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+)
+
+func TestWriteTableBySuite(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := WriteTable(&buffer, reducedSuites, suiteName, enums.GranularitySuite, ','); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "key,report,file,name,time,tests,failed,errors,skipped,assertions,samples\n" +
+		"UserTest,run.xml,test/user_test.rb,UserTest,4.5,2,1,0,0,3,4\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, buffer.String())
+	}
+}
+
+func TestWriteTableByCase(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := WriteTable(&buffer, reducedSuites, suiteName, enums.GranularityCase, '\t'); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	expected := []string{
+		"key\treport\tfile\tclassname\tname\tline\ttime\tassertions\tsamples\tpassed\tfailed\terrored\tskipped",
+		"UserTest\trun.xml\ttest/user_test.rb\tUserTest\ttest_create\t4\t3\t0\t4\t3\t1\t0\t0",
+		"UserTest\trun.xml\ttest/user_test.rb\tUserTest\ttest_update\t9\t1.5\t0\t2\t0\t0\t0\t0",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected %q, but got %q", expected, lines)
+	}
+}
@@ -0,0 +1,137 @@
+package serialization
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+)
+
+// trxTestRun is the part of a TRX (Visual Studio test results) file that
+// holds the results and the classes of the tests they ran.
+type trxTestRun struct {
+	Results         []trxResult         `xml:"Results>UnitTestResult"`
+	TestDefinitions []trxTestDefinition `xml:"TestDefinitions>UnitTest"`
+}
+
+type trxResult struct {
+	TestID    string `xml:"testId,attr"`
+	TestName  string `xml:"testName,attr"`
+	Duration  string `xml:"duration,attr"`
+	StartTime string `xml:"startTime,attr"`
+	Outcome   string `xml:"outcome,attr"`
+	Message   string `xml:"Output>ErrorInfo>Message"`
+	Trace     string `xml:"Output>ErrorInfo>StackTrace"`
+}
+
+type trxTestDefinition struct {
+	ID         string `xml:"id,attr"`
+	Name       string `xml:"name,attr"`
+	TestMethod struct {
+		ClassName string `xml:"className,attr"`
+		Name      string `xml:"name,attr"`
+	} `xml:"TestMethod"`
+}
+
+// DeserializeTRXFromReader reads a TRX file, with a suite per test class and
+// a case per test result.
+func DeserializeTRXFromReader(testSuites []TestSuite, reader io.Reader, fileName string) ([]TestSuite, error) {
+	var testRun trxTestRun
+	if err := xml.NewDecoder(reader).Decode(&testRun); err != nil {
+		helpers.FatalMsg("failed to parse trx: %v\n", err)
+		return nil, err
+	}
+
+	classNames := make(map[string]string)
+	for _, definition := range testRun.TestDefinitions {
+		// Class names may be qualified with their assembly.
+		classNames[definition.ID] = strings.TrimSpace(strings.SplitN(definition.TestMethod.ClassName, ",", 2)[0])
+	}
+
+	suites := make(map[string]*TestSuite)
+	var order []string
+	for _, result := range testRun.Results {
+		className := classNames[result.TestID]
+		suite, ok := suites[className]
+		if !ok {
+			suite = &TestSuite{Name: className, FileName: fileName}
+			suites[className] = suite
+			order = append(order, className)
+		}
+
+		duration, err := parseTRXDuration(result.Duration)
+		if err != nil {
+			helpers.FatalMsg("failed to parse trx duration: %v\n", err)
+			return nil, err
+		}
+		if startTime, err := time.Parse(time.RFC3339Nano, result.StartTime); err == nil {
+			timestamp := startTime.UTC().Format("2006-01-02T15:04:05")
+			if suite.Timestamp == "" || timestamp < suite.Timestamp {
+				suite.Timestamp = timestamp
+			}
+		}
+
+		testCase := TestCase{Name: result.TestName, Classname: className, Time: duration}
+		switch result.Outcome {
+		case "Passed", "PassedButRunAborted", "Warning":
+		case "Failed":
+			testCase.Failure = &Result{Message: result.Message, Text: result.Trace}
+			suite.Failed++
+		case "NotExecuted", "Inconclusive", "NotRunnable", "Disconnected", "Pending", "InProgress":
+			testCase.Skipped = &Result{Message: result.Message}
+			suite.Skipped++
+		default:
+			// Error, Timeout, Aborted and Completed results that didn't pass
+			testCase.Error = &Result{Message: result.Message, Type: result.Outcome, Text: result.Trace}
+			suite.Errors++
+		}
+
+		suite.Tests++
+		suite.Time += duration
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	for _, className := range order {
+		testSuites = append(testSuites, *suites[className])
+	}
+	return testSuites, nil
+}
+
+// parseTRXDuration parses a TRX duration, formatted like "00:00:01.2345678"
+// with days prefixed to the hours when there are any, into seconds.
+func parseTRXDuration(duration string) (float64, error) {
+	if duration == "" {
+		return 0, nil
+	}
+	parts := strings.Split(duration, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid duration '%s'", duration)
+	}
+	days := 0
+	hoursPart := parts[0]
+	if index := strings.Index(hoursPart, "."); index >= 0 {
+		var err error
+		days, err = strconv.Atoi(hoursPart[:index])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration '%s'", duration)
+		}
+		hoursPart = hoursPart[index+1:]
+	}
+	hours, err := strconv.Atoi(hoursPart)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration '%s'", duration)
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration '%s'", duration)
+	}
+	seconds, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration '%s'", duration)
+	}
+	return float64(days*86400+hours*3600+minutes*60) + seconds, nil
+}
@@ -0,0 +1,67 @@
+package serialization
+
+import (
+	"strings"
+	"testing"
+)
+
+const trxReport = `<?xml version="1.0" encoding="utf-8"?>
+<TestRun id="1" name="run" xmlns="http://microsoft.com/schemas/VisualStudio/TeamTest/2010">
+  <Results>
+    <UnitTestResult executionId="e1" testId="t1" testName="CreatesUser" duration="00:00:01.5000000" startTime="2024-03-01T10:00:01.0000000+01:00" outcome="Passed" />
+    <UnitTestResult executionId="e2" testId="t2" testName="UpdatesUser" duration="00:01:00.2500000" startTime="2024-03-01T10:00:00.0000000+01:00" outcome="Failed">
+      <Output>
+        <ErrorInfo>
+          <Message>Assert.Equal() Failure</Message>
+          <StackTrace>at Users.Tests.UserTests.UpdatesUser()</StackTrace>
+        </ErrorInfo>
+      </Output>
+    </UnitTestResult>
+    <UnitTestResult executionId="e3" testId="t3" testName="LogsIn" duration="00:00:00.1000000" outcome="NotExecuted" />
+  </Results>
+  <TestDefinitions>
+    <UnitTest name="CreatesUser" id="t1"><TestMethod className="Users.Tests.UserTests, Users.Tests, Version=1.0.0.0" name="CreatesUser" /></UnitTest>
+    <UnitTest name="UpdatesUser" id="t2"><TestMethod className="Users.Tests.UserTests, Users.Tests, Version=1.0.0.0" name="UpdatesUser" /></UnitTest>
+    <UnitTest name="LogsIn" id="t3"><TestMethod className="Users.Tests.SessionTests" name="LogsIn" /></UnitTest>
+  </TestDefinitions>
+</TestRun>`
+
+func TestDeserializeTRXFromReader(t *testing.T) {
+	testSuites, err := DeserializeTRXFromReader(nil, strings.NewReader(trxReport), "run.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(testSuites) != 2 {
+		t.Fatalf("Expected a suite per test class, but got %d", len(testSuites))
+	}
+	users := testSuites[0]
+	if users.Name != "Users.Tests.UserTests" || users.Time != 61.75 || users.Tests != 2 || users.Failed != 1 || users.Timestamp != "2024-03-01T09:00:00" {
+		t.Errorf("Expected the user tests suite, but got %+v", users)
+	}
+	failed := users.TestCases[1]
+	if failed.Name != "UpdatesUser" || failed.Classname != "Users.Tests.UserTests" || failed.Failure == nil || failed.Failure.Message != "Assert.Equal() Failure" {
+		t.Errorf("Expected the failed case, but got %+v", failed)
+	}
+	if testSuites[1].Skipped != 1 || testSuites[1].TestCases[0].Skipped == nil {
+		t.Errorf("Expected the case that wasn't executed to be skipped, but got %+v", testSuites[1])
+	}
+}
+
+func TestParseTRXDuration(t *testing.T) {
+	tests := map[string]float64{
+		"00:00:01.2500000": 1.25,
+		"01:02:03":         3723,
+		"1.00:00:00":       86400,
+		"":                 0,
+	}
+	for duration, expected := range tests {
+		actual, err := parseTRXDuration(duration)
+		if err != nil || actual != expected {
+			t.Errorf("Expected %q to be %v seconds, but got %v (%v)", duration, expected, actual, err)
+		}
+	}
+	if _, err := parseTRXDuration("1.5"); err == nil {
+		t.Errorf("Expected an error for an invalid duration")
+	}
+}
@@ -0,0 +1,103 @@
+package serialization
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+)
+
+// xunitAssembly is an assembly of an xUnit.net v2 result file. Files hold
+// either a single assembly or several in an assemblies element.
+type xunitAssembly struct {
+	RunDate     string            `xml:"run-date,attr"`
+	RunTime     string            `xml:"run-time,attr"`
+	Collections []xunitCollection `xml:"collection"`
+}
+
+type xunitCollection struct {
+	Tests []xunitTest `xml:"test"`
+}
+
+type xunitTest struct {
+	Name    string  `xml:"name,attr"`
+	Type    string  `xml:"type,attr"`
+	Result  string  `xml:"result,attr"`
+	Time    float64 `xml:"time,attr"`
+	Reason  string  `xml:"reason"`
+	Failure struct {
+		ExceptionType string `xml:"exception-type,attr"`
+		Message       string `xml:"message"`
+		Trace         string `xml:"stack-trace"`
+	} `xml:"failure"`
+}
+
+// DeserializeXUnitFromReader reads an xUnit.net v2 result file, with a suite
+// per test class and a case per test.
+func DeserializeXUnitFromReader(testSuites []TestSuite, reader io.Reader, fileName string) ([]TestSuite, error) {
+	var assemblies []xunitAssembly
+	decoder := xml.NewDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			helpers.FatalMsg("failed to parse xunit xml: %v\n", err)
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "assembly" {
+			continue
+		}
+		var assembly xunitAssembly
+		if err := decoder.DecodeElement(&assembly, &start); err != nil {
+			helpers.FatalMsg("failed to parse xunit xml: %v\n", err)
+			return nil, err
+		}
+		assemblies = append(assemblies, assembly)
+	}
+
+	for _, assembly := range assemblies {
+		timestamp := ""
+		if assembly.RunDate != "" && assembly.RunTime != "" {
+			timestamp = assembly.RunDate + "T" + assembly.RunTime
+		}
+
+		suites := make(map[string]*TestSuite)
+		var order []string
+		for _, collection := range assembly.Collections {
+			for _, test := range collection.Tests {
+				suite, ok := suites[test.Type]
+				if !ok {
+					suite = &TestSuite{Name: test.Type, FileName: fileName, Timestamp: timestamp}
+					suites[test.Type] = suite
+					order = append(order, test.Type)
+				}
+
+				testCase := TestCase{
+					Name:      strings.TrimPrefix(test.Name, test.Type+"."),
+					Classname: test.Type,
+					Time:      test.Time,
+				}
+				switch test.Result {
+				case "Fail":
+					testCase.Failure = &Result{Message: test.Failure.Message, Type: test.Failure.ExceptionType, Text: test.Failure.Trace}
+					suite.Failed++
+				case "Skip", "NotRun":
+					testCase.Skipped = &Result{Message: test.Reason}
+					suite.Skipped++
+				}
+				suite.Tests++
+				suite.Time += test.Time
+				suite.TestCases = append(suite.TestCases, testCase)
+			}
+		}
+
+		for _, name := range order {
+			testSuites = append(testSuites, *suites[name])
+		}
+	}
+	return testSuites, nil
+}
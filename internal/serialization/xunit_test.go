@@ -0,0 +1,53 @@
+package serialization
+
+import (
+	"strings"
+	"testing"
+)
+
+const xunitReport = `<?xml version="1.0" encoding="utf-8"?>
+<assemblies timestamp="03/01/2024 10:00:00">
+  <assembly name="bin/Users.Tests.dll" run-date="2024-03-01" run-time="10:00:00" time="2.5" total="3" passed="1" failed="1" skipped="1">
+    <collection name="Test collection for Users.UserTests" time="2.5" total="3">
+      <test name="Users.UserTests.Creates" type="Users.UserTests" method="Creates" time="1.5" result="Pass" />
+      <test name="Users.UserTests.Updates(role: &quot;admin&quot;)" type="Users.UserTests" method="Updates" time="0.75" result="Fail">
+        <failure exception-type="Xunit.Sdk.EqualException"><message>Assert.Equal() Failure</message><stack-trace>at Users.UserTests.Updates()</stack-trace></failure>
+      </test>
+      <test name="Users.SessionTests.LogsIn" type="Users.SessionTests" method="LogsIn" time="0" result="Skip"><reason>Not ready</reason></test>
+    </collection>
+  </assembly>
+</assemblies>`
+
+func TestDeserializeXUnitFromReader(t *testing.T) {
+	testSuites, err := DeserializeXUnitFromReader(nil, strings.NewReader(xunitReport), "xunit.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(testSuites) != 2 {
+		t.Fatalf("Expected a suite per test class, but got %d", len(testSuites))
+	}
+	users := testSuites[0]
+	if users.Name != "Users.UserTests" || users.Time != 2.25 || users.Tests != 2 || users.Failed != 1 || users.Timestamp != "2024-03-01T10:00:00" {
+		t.Errorf("Expected the user tests suite, but got %+v", users)
+	}
+	failed := users.TestCases[1]
+	if failed.Name != `Updates(role: "admin")` || failed.Failure == nil || failed.Failure.Type != "Xunit.Sdk.EqualException" {
+		t.Errorf("Expected the failed case, but got %+v", failed)
+	}
+	if testSuites[1].Skipped != 1 || testSuites[1].TestCases[0].Skipped.Message != "Not ready" {
+		t.Errorf("Expected the skipped case, but got %+v", testSuites[1])
+	}
+}
+
+func TestDeserializeXUnitSingleAssembly(t *testing.T) {
+	report := `<assembly name="Users.Tests.dll"><collection><test name="Users.UserTests.Creates" type="Users.UserTests" time="1" result="Pass" /></collection></assembly>`
+	testSuites, err := DeserializeXUnitFromReader(nil, strings.NewReader(report), "xunit.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(testSuites) != 1 || testSuites[0].TestCases[0].Name != "Creates" {
+		t.Errorf("Expected the assembly to be read, but got %+v", testSuites)
+	}
+}
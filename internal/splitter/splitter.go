@@ -0,0 +1,236 @@
+package splitter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"github.com/bmatcuk/doublestar"
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+// Item is a unit of work to place on a shard: a test file for suite
+// granularity, or a single test case.
+type Item struct {
+	Name string
+	// Test file the item belongs to, if known
+	File string
+	Time float64
+}
+
+type Shard struct {
+	Index int      `json:"index"`
+	Time  float64  `json:"time"`
+	Items []string `json:"items"`
+}
+
+type Plan struct {
+	Shards []Shard `json:"shards"`
+}
+
+// Items flattens reduced test suites into items, summing the times of suites
+// or cases that share an item name.
+func Items(testSuites []serialization.TestSuite, granularity enums.Granularity) []Item {
+	var items []Item
+	indexes := make(map[string]int)
+
+	add := func(name string, file string, time float64) {
+		index, ok := indexes[name]
+		if !ok {
+			index = len(items)
+			indexes[name] = index
+			items = append(items, Item{Name: name, File: file})
+		}
+		items[index].Time += time
+	}
+
+	for _, testSuite := range testSuites {
+		if granularity == enums.GranularityCase {
+			for _, testCase := range testSuite.TestCases {
+				// Cases without a file belong to the file of their suite.
+				if testCase.File == "" {
+					testCase.File = testSuite.File
+				}
+				add(serialization.CaseID(testCase), testCase.File, testCase.Time)
+			}
+		} else {
+			add(serialization.SuiteID(testSuite), testSuite.File, testSuite.Time)
+		}
+	}
+
+	return items
+}
+
+// FindTestFiles lists the test files currently on disk matching the glob
+// patterns.
+func FindTestFiles(patterns []string) ([]string, error) {
+	var testFiles []string
+	for _, pattern := range patterns {
+		matches, err := doublestar.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		testFiles = append(testFiles, matches...)
+	}
+	return testFiles, nil
+}
+
+// Reconcile brings items in line with the test files currently on disk.
+// Items from deleted files are dropped, and files without any history are
+// added as untimed items so they get the fallback time. Items that don't
+// record their file are kept as they are.
+func Reconcile(items []Item, testFiles []string) []Item {
+	onDisk := make(map[string]bool)
+	for _, testFile := range testFiles {
+		onDisk[normalizePath(testFile)] = true
+	}
+
+	reconciled := make([]Item, 0, len(items))
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if item.File == "" {
+			reconciled = append(reconciled, item)
+			continue
+		}
+		file := normalizePath(item.File)
+		if onDisk[file] {
+			seen[file] = true
+			reconciled = append(reconciled, item)
+		}
+	}
+
+	newFiles := make([]string, 0)
+	for file := range onDisk {
+		if !seen[file] {
+			newFiles = append(newFiles, file)
+		}
+	}
+	sort.Strings(newFiles)
+	for _, file := range newFiles {
+		reconciled = append(reconciled, Item{Name: file, File: file})
+	}
+
+	return reconciled
+}
+
+func normalizePath(path string) string {
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// Split balances items across shardTotal shards using the longest processing
+// time (LPT) heuristic: items are placed longest first onto the least loaded
+// shard. Ties are broken by item name and shard index, so every runner given
+// the same items computes the same plan. Items without a time are estimated
+// with fallbackTime, or the mean time of the timed items when that is zero.
+func Split(items []Item, shardTotal int, fallbackTime float64) Plan {
+	if fallbackTime <= 0 {
+		fallbackTime = meanTime(items)
+	}
+
+	estimated := make([]Item, len(items))
+	for i, item := range items {
+		estimated[i] = item
+		if item.Time <= 0 {
+			estimated[i].Time = fallbackTime
+		}
+	}
+
+	sort.Slice(estimated, func(i, j int) bool {
+		if estimated[i].Time != estimated[j].Time {
+			return estimated[i].Time > estimated[j].Time
+		}
+		return estimated[i].Name < estimated[j].Name
+	})
+
+	shards := make([]Shard, shardTotal)
+	for i := range shards {
+		shards[i] = Shard{Index: i, Items: []string{}}
+	}
+
+	for _, item := range estimated {
+		lightest := 0
+		for i := range shards {
+			if shards[i].Time < shards[lightest].Time {
+				lightest = i
+			}
+		}
+		shards[lightest].Time += item.Time
+		shards[lightest].Items = append(shards[lightest].Items, item.Name)
+	}
+
+	for i := range shards {
+		sort.Strings(shards[i].Items)
+	}
+
+	return Plan{Shards: shards}
+}
+
+func meanTime(items []Item) float64 {
+	var total float64 = 0
+	timed := 0
+	for _, item := range items {
+		if item.Time > 0 {
+			total += item.Time
+			timed++
+		}
+	}
+	if timed == 0 {
+		return 1
+	}
+	return total / float64(timed)
+}
+
+// WriteShard writes the items of a single shard, so a runner can feed its
+// share straight into the test command. Text output is one item per line.
+func WriteShard(writer io.Writer, shard Shard, format enums.ReportFormat) error {
+	if format != enums.ReportFormatText {
+		return Write(writer, Plan{Shards: []Shard{shard}}, format)
+	}
+	for _, item := range shard.Items {
+		if _, err := fmt.Fprintln(writer, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Write(writer io.Writer, plan Plan, format enums.ReportFormat) error {
+	if format == enums.ReportFormatJSON {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(plan)
+	}
+
+	for _, shard := range plan.Shards {
+		var err error
+		if format == enums.ReportFormatMarkdown {
+			_, err = fmt.Fprintf(writer, "## Shard %d (%.2fs)\n\n", shard.Index, shard.Time)
+		} else {
+			_, err = fmt.Fprintf(writer, "shard %d (%.2fs)\n", shard.Index, shard.Time)
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, item := range shard.Items {
+			if format == enums.ReportFormatMarkdown {
+				_, err = fmt.Fprintf(writer, "- `%s`\n", item)
+			} else {
+				_, err = fmt.Fprintf(writer, "  %s\n", item)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if format == enums.ReportFormatMarkdown {
+			if _, err = fmt.Fprintln(writer); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package splitter
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+var testSuites = []serialization.TestSuite{
+	{
+		Name: "UserTest",
+		File: "test/user_test.rb",
+		Time: 8,
+		TestCases: []serialization.TestCase{
+			{Name: "test_create", File: "test/user_test.rb", Time: 5},
+			{Name: "test_update", File: "test/user_test.rb", Time: 3},
+		},
+	},
+	{
+		Name: "UserValidationTest",
+		File: "test/user_test.rb",
+		Time: 2,
+		TestCases: []serialization.TestCase{
+			{Name: "test_email", Classname: "UserValidationTest", Time: 2},
+		},
+	},
+	{
+		Name: "AccountTest",
+		File: "test/account_test.rb",
+		Time: 6,
+	},
+	{
+		Name: "HelperTest",
+		Time: 4,
+	},
+}
+
+func TestSuiteItems(t *testing.T) {
+	items := Items(testSuites, enums.GranularitySuite)
+	expectedItems := []Item{
+		{Name: "test/user_test.rb", File: "test/user_test.rb", Time: 10},
+		{Name: "test/account_test.rb", File: "test/account_test.rb", Time: 6},
+		{Name: "HelperTest", Time: 4},
+	}
+
+	if !reflect.DeepEqual(items, expectedItems) {
+		t.Errorf("expected items %v, got %v", expectedItems, items)
+	}
+}
+
+func TestCaseItems(t *testing.T) {
+	items := Items(testSuites, enums.GranularityCase)
+	expectedItems := []Item{
+		{Name: "test/user_test.rb::test_create", File: "test/user_test.rb", Time: 5},
+		{Name: "test/user_test.rb::test_update", File: "test/user_test.rb", Time: 3},
+		{Name: "test/user_test.rb::test_email", File: "test/user_test.rb", Time: 2},
+	}
+
+	if !reflect.DeepEqual(items, expectedItems) {
+		t.Errorf("expected items %v, got %v", expectedItems, items)
+	}
+}
+
+func TestReconcileCasesWithoutFile(t *testing.T) {
+	// Cases that don't record their file are matched by their suite's file,
+	// so it isn't scheduled again as a new file.
+	items := Reconcile(Items(testSuites[1:2], enums.GranularityCase), []string{"test/user_test.rb"})
+	expectedItems := []Item{
+		{Name: "test/user_test.rb::test_email", File: "test/user_test.rb", Time: 2},
+	}
+
+	if !reflect.DeepEqual(items, expectedItems) {
+		t.Errorf("expected items %v, got %v", expectedItems, items)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	items := []Item{
+		{Name: "./test/user_test.rb", File: "./test/user_test.rb", Time: 10},
+		{Name: "test/deleted_test.rb", File: "test/deleted_test.rb", Time: 6},
+		{Name: "HelperTest", Time: 4},
+	}
+
+	reconciled := Reconcile(items, []string{"test/user_test.rb", "test/new_test.rb"})
+	expectedItems := []Item{
+		{Name: "./test/user_test.rb", File: "./test/user_test.rb", Time: 10},
+		{Name: "HelperTest", Time: 4},
+		{Name: "test/new_test.rb", File: "test/new_test.rb"},
+	}
+
+	if !reflect.DeepEqual(reconciled, expectedItems) {
+		t.Errorf("expected items %v, got %v", expectedItems, reconciled)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	items := []Item{
+		{Name: "a", Time: 7},
+		{Name: "b", Time: 5},
+		{Name: "c", Time: 4},
+		{Name: "d", Time: 3},
+		{Name: "e", Time: 3},
+		{Name: "f", Time: 2},
+	}
+
+	plan := Split(items, 2, 0)
+
+	expectedShards := []Shard{
+		{Index: 0, Time: 12, Items: []string{"a", "d", "f"}},
+		{Index: 1, Time: 12, Items: []string{"b", "c", "e"}},
+	}
+
+	if !reflect.DeepEqual(plan.Shards, expectedShards) {
+		t.Errorf("expected shards %v, got %v", expectedShards, plan.Shards)
+	}
+}
+
+func TestSplitFallbackTime(t *testing.T) {
+	items := []Item{
+		{Name: "timed", Time: 6},
+		{Name: "other", Time: 2},
+		{Name: "new", Time: 0},
+	}
+
+	plan := Split(items, 3, 0)
+	if plan.Shards[1].Items[0] != "new" || plan.Shards[1].Time != 4 {
+		t.Errorf("expected untimed item to be estimated at the mean time of 4, got shard %v", plan.Shards[1])
+	}
+
+	plan = Split(items, 3, 10)
+	if plan.Shards[0].Items[0] != "new" || plan.Shards[0].Time != 10 {
+		t.Errorf("expected untimed item to be estimated at the fallback time of 10, got shard %v", plan.Shards[0])
+	}
+}
+
+func TestSplitMoreShardsThanItems(t *testing.T) {
+	plan := Split([]Item{{Name: "only", Time: 1}}, 3, 0)
+
+	if len(plan.Shards) != 3 {
+		t.Fatalf("expected 3 shards, got %d", len(plan.Shards))
+	}
+
+	if len(plan.Shards[2].Items) != 0 {
+		t.Errorf("expected empty shard, got %v", plan.Shards[2].Items)
+	}
+}
+
+func TestWriteShard(t *testing.T) {
+	var output bytes.Buffer
+
+	err := WriteShard(&output, Shard{Index: 1, Time: 3, Items: []string{"a", "b"}}, enums.ReportFormatText)
+	if err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+
+	if output.String() != "a\nb\n" {
+		t.Errorf("expected one item per line, got %q", output.String())
+	}
+}
+
+func TestWrite(t *testing.T) {
+	plan := Plan{Shards: []Shard{{Index: 0, Time: 1.5, Items: []string{"a", "b"}}}}
+
+	expectedOutputs := map[enums.ReportFormat]string{
+		enums.ReportFormatText:     "shard 0 (1.50s)\n  a\n  b\n",
+		enums.ReportFormatMarkdown: "## Shard 0 (1.50s)\n\n- `a`\n- `b`\n\n",
+		enums.ReportFormatJSON:     `"items": [`,
+	}
+
+	for format, expectedOutput := range expectedOutputs {
+		var output bytes.Buffer
+		err := Write(&output, plan, format)
+		if err != nil {
+			t.Errorf("expected no error, got %s", err)
+		}
+		if !strings.Contains(output.String(), expectedOutput) {
+			t.Errorf("expected %s output to contain %q, got %q", enums.ReportFormatKeys[format], expectedOutput, output.String())
+		}
+	}
+}
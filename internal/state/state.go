@@ -0,0 +1,208 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+// Version is bumped whenever the state file format changes incompatibly.
+const Version = 2
+
+// ReservoirSize is the number of values kept per accumulator for medians and
+// modes. Both are exact until a group has seen more values than this.
+const ReservoirSize = 128
+
+// Accumulator summarizes every value seen for a field, so new values can be
+// folded in without re-reading the old ones. Values from reduced reports are
+// weighted by the number of runs they were reduced from.
+type Accumulator struct {
+	// Number of runs folded in
+	Count int `json:"count"`
+	// Sums of the values and their squares, weighted by runs
+	Sum        float64 `json:"sum"`
+	SumSquares float64 `json:"sum_squares"`
+	// Plain sum of the values, for sum reductions
+	Total   float64   `json:"total"`
+	Min     float64   `json:"min"`
+	Max     float64   `json:"max"`
+	Samples []float64 `json:"samples"`
+}
+
+// Add folds a value standing for a number of runs into the accumulator. The
+// reservoir sees the value once per run. Once it's full, each run replaces a
+// sample with probability ReservoirSize/Count, keeping a uniform sample of
+// every run seen. Replacements are chosen by a hash of the count, so folding
+// the same reports always yields the same state.
+func (accumulator *Accumulator) Add(value float64, weight int) {
+	if weight < 1 {
+		weight = 1
+	}
+	if accumulator.Count == 0 || value < accumulator.Min {
+		accumulator.Min = value
+	}
+	if accumulator.Count == 0 || value > accumulator.Max {
+		accumulator.Max = value
+	}
+	accumulator.Sum += value * float64(weight)
+	accumulator.SumSquares += value * value * float64(weight)
+	accumulator.Total += value
+
+	for i := 0; i < weight; i++ {
+		accumulator.Count++
+		if len(accumulator.Samples) < ReservoirSize {
+			accumulator.Samples = append(accumulator.Samples, value)
+			continue
+		}
+		index := splitmix64(uint64(accumulator.Count)) % uint64(accumulator.Count)
+		if index < ReservoirSize {
+			accumulator.Samples[index] = value
+		}
+	}
+}
+
+func (accumulator Accumulator) Mean() float64 {
+	if accumulator.Count == 0 {
+		return 0
+	}
+	return accumulator.Sum / float64(accumulator.Count)
+}
+
+// Variance is the population variance of the values seen.
+func (accumulator Accumulator) Variance() float64 {
+	if accumulator.Count == 0 {
+		return 0
+	}
+	mean := accumulator.Mean()
+	variance := accumulator.SumSquares/float64(accumulator.Count) - mean*mean
+	if variance < 0 {
+		return 0
+	}
+	return variance
+}
+
+func splitmix64(seed uint64) uint64 {
+	seed += 0x9e3779b97f4a7c15
+	seed = (seed ^ (seed >> 30)) * 0xbf58476d1ce4e5b9
+	seed = (seed ^ (seed >> 27)) * 0x94d049bb133111eb
+	return seed ^ (seed >> 31)
+}
+
+type Case struct {
+	Name       string                 `json:"name"`
+	Classname  string                 `json:"classname"`
+	File       string                 `json:"file"`
+	Line       int                    `json:"line"`
+	Assertions int                    `json:"assertions"`
+	Time       Accumulator            `json:"time"`
+	Outcomes   serialization.Outcomes `json:"outcomes"`
+}
+
+// Suite holds the accumulators of a group of suites, along with the fields
+// of the first suite seen, which the reduced suite is based on.
+type Suite struct {
+	Name       string           `json:"name"`
+	File       string           `json:"file"`
+	FileName   string           `json:"file_name"`
+	Time       Accumulator      `json:"time"`
+	Tests      Accumulator      `json:"tests"`
+	Failed     Accumulator      `json:"failed"`
+	Errors     Accumulator      `json:"errors"`
+	Skipped    Accumulator      `json:"skipped"`
+	Assertions Accumulator      `json:"assertions"`
+	Cases      map[string]*Case `json:"cases"`
+}
+
+// State is the persisted form of an incremental reduction. Suites and cases
+// are keyed by the same keys they are reduced by, which are recorded so a
+// state isn't folded into with different keys.
+type State struct {
+	Version        int               `json:"version"`
+	ReduceSuitesBy string            `json:"reduce_suites_by"`
+	ReduceCasesBy  string            `json:"reduce_cases_by"`
+	Reports        map[string]bool   `json:"reports"`
+	Suites         map[string]*Suite `json:"suites"`
+}
+
+func New(reduceSuitesBy string, reduceCasesBy string) *State {
+	return &State{
+		Version:        Version,
+		ReduceSuitesBy: reduceSuitesBy,
+		ReduceCasesBy:  reduceCasesBy,
+		Reports:        make(map[string]bool),
+		Suites:         make(map[string]*Suite),
+	}
+}
+
+// Load reads a state file, returning a new state when it doesn't exist yet.
+func Load(path string, reduceSuitesBy string, reduceCasesBy string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return New(reduceSuitesBy, reduceCasesBy), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %v", path, err)
+	}
+	if state.Version != Version {
+		return nil, fmt.Errorf("state file %s has version %d, expected %d", path, state.Version, Version)
+	}
+	if state.ReduceSuitesBy != reduceSuitesBy || state.ReduceCasesBy != reduceCasesBy {
+		return nil, fmt.Errorf("state file %s was reduced by suite %s and case %s, not by suite %s and case %s", path, state.ReduceSuitesBy, state.ReduceCasesBy, reduceSuitesBy, reduceCasesBy)
+	}
+	if state.Reports == nil {
+		state.Reports = make(map[string]bool)
+	}
+	if state.Suites == nil {
+		state.Suites = make(map[string]*Suite)
+	}
+	return &state, nil
+}
+
+// Save writes the state through a temporary file, so an interrupted run
+// never leaves a truncated state behind.
+func (state *State) Save(path string) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// Digest identifies a report by its content, so a report folded into the
+// state is recognized again even when it's downloaded to a new path.
+func Digest(reader io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
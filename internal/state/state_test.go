@@ -0,0 +1,117 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAccumulatorAdd(t *testing.T) {
+	var accumulator Accumulator
+	for _, value := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+		accumulator.Add(value, 1)
+	}
+
+	if accumulator.Count != 8 || accumulator.Sum != 40 || accumulator.Min != 2 || accumulator.Max != 9 {
+		t.Errorf("Expected count 8, sum 40, min 2 and max 9, but got %+v", accumulator)
+	}
+	if accumulator.Mean() != 5 {
+		t.Errorf("Expected mean 5, but got %v", accumulator.Mean())
+	}
+	if accumulator.Variance() != 4 {
+		t.Errorf("Expected variance 4, but got %v", accumulator.Variance())
+	}
+}
+
+func TestAccumulatorReservoirIsBoundedAndDeterministic(t *testing.T) {
+	var first, second Accumulator
+	for i := 0; i < ReservoirSize*10; i++ {
+		first.Add(float64(i), 1)
+		second.Add(float64(i), 1)
+	}
+
+	if len(first.Samples) != ReservoirSize {
+		t.Errorf("Expected %d samples, but got %d", ReservoirSize, len(first.Samples))
+	}
+	if !reflect.DeepEqual(first.Samples, second.Samples) {
+		t.Errorf("Expected the same values to yield the same samples")
+	}
+
+	replaced := 0
+	for i, sample := range first.Samples {
+		if sample != float64(i) {
+			replaced++
+		}
+	}
+	if replaced == 0 {
+		t.Errorf("Expected later values to replace some samples")
+	}
+}
+
+func TestLoadMissingStateFile(t *testing.T) {
+	state, err := Load(filepath.Join(t.TempDir(), "state.json"), "name+filepath", "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Suites) != 0 || state.Version != Version {
+		t.Errorf("Expected a new state, but got %+v", state)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+
+	state := New("name+filepath", "name")
+	state.Reports["abc"] = true
+	suite := &Suite{Name: "UserTest", Cases: map[string]*Case{"test_create": {Name: "test_create"}}}
+	suite.Time.Add(3, 1)
+	suite.Cases["test_create"].Time.Add(1, 1)
+	state.Suites["UserTest"] = suite
+
+	if err := state.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path, "name+filepath", "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, state) {
+		t.Errorf("Expected %+v, but got %+v", state, loaded)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected only the state file to remain, but got %d files", len(entries))
+	}
+
+	_, err = Load(path, "name", "name")
+	if err == nil || !strings.Contains(err.Error(), "reduced by suite name+filepath") {
+		t.Errorf("Expected an error for different keys, but got %v", err)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	first, _ := Digest(strings.NewReader("<testsuites/>"))
+	second, _ := Digest(strings.NewReader("<testsuites/>"))
+	other, _ := Digest(strings.NewReader("<testsuites></testsuites>"))
+
+	if first != second || first == other {
+		t.Errorf("Expected digests to identify content, but got %s, %s and %s", first, second, other)
+	}
+}
+
+func TestAccumulatorAddWeighted(t *testing.T) {
+	var accumulator Accumulator
+	accumulator.Add(10, 2)
+	accumulator.Add(2, 6)
+
+	if accumulator.Count != 8 || accumulator.Mean() != 4 || accumulator.Total != 12 {
+		t.Errorf("Expected 8 runs averaging 4 and totalling 12, but got %+v", accumulator)
+	}
+	if len(accumulator.Samples) != 8 {
+		t.Errorf("Expected a sample per run, but got %v", accumulator.Samples)
+	}
+}
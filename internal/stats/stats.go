@@ -0,0 +1,374 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/helpers"
+	"github.com/willgeorgetaylor/junit-reducer/internal/reducer"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+type Totals struct {
+	Reports     int     `json:"reports"`
+	Suites      int     `json:"suites"`
+	Cases       int     `json:"cases"`
+	SuiteGroups int     `json:"suite_groups"`
+	CaseGroups  int     `json:"case_groups"`
+	Time        float64 `json:"time"`
+	Tests       int     `json:"tests"`
+	Failed      int     `json:"failed"`
+	Errors      int     `json:"errors"`
+	Skipped     int     `json:"skipped"`
+}
+
+// Group summarizes the samples of a suite or case grouped by the reducer
+// keys.
+type Group struct {
+	Key      string  `json:"key"`
+	Name     string  `json:"name"`
+	File     string  `json:"file"`
+	Samples  int     `json:"samples"`
+	MeanTime float64 `json:"mean_time"`
+	MinTime  float64 `json:"min_time"`
+	MaxTime  float64 `json:"max_time"`
+}
+
+// Bucket counts the groups whose mean time is at least Min and less than Max.
+// The last bucket has no upper bound, which is written as a Max of zero.
+type Bucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+type FileCount struct {
+	File   string  `json:"file"`
+	Suites int     `json:"suites"`
+	Cases  int     `json:"cases"`
+	Time   float64 `json:"time"`
+}
+
+type SampleCounts struct {
+	Min  int     `json:"min"`
+	Max  int     `json:"max"`
+	Mean float64 `json:"mean"`
+}
+
+type Report struct {
+	Totals         Totals       `json:"totals"`
+	Samples        SampleCounts `json:"samples_per_suite_group"`
+	SlowestSuites  []Group      `json:"slowest_suites"`
+	SlowestCases   []Group      `json:"slowest_cases"`
+	SuiteHistogram []Bucket     `json:"suite_time_histogram"`
+	CaseHistogram  []Bucket     `json:"case_time_histogram"`
+	Files          []FileCount  `json:"files"`
+}
+
+// Upper bounds in seconds of the histogram buckets, before the unbounded
+// final bucket.
+var bucketBounds = []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300}
+
+type sampleGroup struct {
+	Group
+	total float64
+}
+
+// add counts a time that stands for weight runs, as reduced suites and cases
+// do.
+func (group *sampleGroup) add(time float64, weight int) {
+	if group.Samples == 0 || time < group.MinTime {
+		group.MinTime = time
+	}
+	if group.Samples == 0 || time > group.MaxTime {
+		group.MaxTime = time
+	}
+	group.Samples += weight
+	group.total += time * float64(weight)
+	group.MeanTime = group.total / float64(group.Samples)
+}
+
+// Summarize builds a report over every suite and case, grouped by the same
+// keys used to reduce them, listing the top slowest groups. Reduced suites
+// and cases count as the number of runs they were reduced from.
+func Summarize(testSuites []serialization.TestSuite, suitesBy enums.TestSuiteField, casesBy enums.TestCaseField, top int) Report {
+	var report Report
+
+	reports := make(map[string]bool)
+	suiteGroups := make(map[string]*sampleGroup)
+	caseGroups := make(map[string]*sampleGroup)
+
+	for _, testSuite := range testSuites {
+		weight := serialization.SuiteWeight(testSuite)
+		reports[testSuite.FileName] = true
+		report.Totals.Suites += weight
+		report.Totals.Time += testSuite.Time * float64(weight)
+		report.Totals.Tests += testSuite.Tests * weight
+		report.Totals.Failed += testSuite.Failed * weight
+		report.Totals.Errors += testSuite.Errors * weight
+		report.Totals.Skipped += testSuite.Skipped * weight
+
+		suiteKey := reducer.SuiteKey(testSuite, suitesBy)
+		suiteGroup, ok := suiteGroups[suiteKey]
+		if !ok {
+			suiteGroup = &sampleGroup{Group: Group{Key: suiteKey, Name: testSuite.Name, File: testSuite.File}}
+			suiteGroups[suiteKey] = suiteGroup
+		}
+		suiteGroup.add(testSuite.Time, weight)
+
+		for _, testCase := range testSuite.TestCases {
+			report.Totals.Cases += serialization.CaseWeight(testCase)
+			caseKey := suiteKey + " > " + reducer.CaseKey(testCase, casesBy)
+			caseGroup, ok := caseGroups[caseKey]
+			if !ok {
+				caseFile := testCase.File
+				if caseFile == "" {
+					caseFile = testSuite.File
+				}
+				caseGroup = &sampleGroup{Group: Group{Key: caseKey, Name: testCase.Name, File: caseFile}}
+				caseGroups[caseKey] = caseGroup
+			}
+			caseGroup.add(testCase.Time, serialization.CaseWeight(testCase))
+		}
+	}
+
+	report.Totals.Reports = len(reports)
+	report.Totals.SuiteGroups = len(suiteGroups)
+	report.Totals.CaseGroups = len(caseGroups)
+
+	suites := sortedBySlowest(suiteGroups)
+	cases := sortedBySlowest(caseGroups)
+
+	report.Samples = countSamples(suites)
+	report.SlowestSuites = firstN(suites, top)
+	report.SlowestCases = firstN(cases, top)
+	report.SuiteHistogram = histogram(suites)
+	report.CaseHistogram = histogram(cases)
+	report.Files = countFiles(suites, cases)
+
+	return report
+}
+
+func sortedBySlowest(groups map[string]*sampleGroup) []Group {
+	sorted := make([]Group, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, group.Group)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].MeanTime != sorted[j].MeanTime {
+			return sorted[i].MeanTime > sorted[j].MeanTime
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+	return sorted
+}
+
+func firstN(groups []Group, n int) []Group {
+	if n >= 0 && len(groups) > n {
+		return groups[:n]
+	}
+	return groups
+}
+
+func countSamples(groups []Group) SampleCounts {
+	var counts SampleCounts
+	if len(groups) == 0 {
+		return counts
+	}
+	counts.Min = math.MaxInt
+	total := 0
+	for _, group := range groups {
+		if group.Samples < counts.Min {
+			counts.Min = group.Samples
+		}
+		if group.Samples > counts.Max {
+			counts.Max = group.Samples
+		}
+		total += group.Samples
+	}
+	counts.Mean = float64(total) / float64(len(groups))
+	return counts
+}
+
+func histogram(groups []Group) []Bucket {
+	buckets := make([]Bucket, len(bucketBounds)+1)
+	for i := range buckets {
+		if i > 0 {
+			buckets[i].Min = bucketBounds[i-1]
+		}
+		if i < len(bucketBounds) {
+			buckets[i].Max = bucketBounds[i]
+		}
+	}
+	for _, group := range groups {
+		index := sort.SearchFloat64s(bucketBounds, group.MeanTime)
+		// SearchFloat64s finds the first bound >= time, but a time equal to
+		// a bound belongs in the bucket above it.
+		if index < len(bucketBounds) && bucketBounds[index] == group.MeanTime {
+			index++
+		}
+		buckets[index].Count++
+	}
+	return buckets
+}
+
+func countFiles(suites []Group, cases []Group) []FileCount {
+	counts := make(map[string]*FileCount)
+	get := func(file string) *FileCount {
+		count, ok := counts[file]
+		if !ok {
+			count = &FileCount{File: file}
+			counts[file] = count
+		}
+		return count
+	}
+	for _, suite := range suites {
+		count := get(suite.File)
+		count.Suites++
+		count.Time += suite.MeanTime
+	}
+	for _, testCase := range cases {
+		get(testCase.File).Cases++
+	}
+
+	files := make([]FileCount, 0, len(counts))
+	for _, count := range counts {
+		files = append(files, *count)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].File < files[j].File
+	})
+	return files
+}
+
+func bucketLabel(bucket Bucket) string {
+	if bucket.Max == 0 {
+		return fmt.Sprintf(">= %gs", bucket.Min)
+	}
+	return fmt.Sprintf("%gs - %gs", bucket.Min, bucket.Max)
+}
+
+func Write(writer io.Writer, report Report, format enums.ReportFormat) error {
+	if format == enums.ReportFormatJSON {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	if format == enums.ReportFormatMarkdown {
+		return writeMarkdown(writer, report)
+	}
+	return writeText(writer, report)
+}
+
+// writeText writes each section as its own aligned table, so long keys in
+// one section don't widen the others.
+func writeText(writer io.Writer, report Report) error {
+	totals := report.Totals
+
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "TOTALS\n")
+	fmt.Fprintf(table, "reports\t%d\n", totals.Reports)
+	fmt.Fprintf(table, "suites\t%d (%d groups)\n", totals.Suites, totals.SuiteGroups)
+	fmt.Fprintf(table, "cases\t%d (%d groups)\n", totals.Cases, totals.CaseGroups)
+	fmt.Fprintf(table, "time\t%.2fs\n", totals.Time)
+	fmt.Fprintf(table, "tests\t%d (%d failed, %d errors, %d skipped)\n", totals.Tests, totals.Failed, totals.Errors, totals.Skipped)
+	fmt.Fprintf(table, "samples per suite group\tmin %d, max %d, mean %.1f\n", report.Samples.Min, report.Samples.Max, report.Samples.Mean)
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	if err := writeGroupsText(writer, "SLOWEST SUITES", report.SlowestSuites); err != nil {
+		return err
+	}
+	if err := writeGroupsText(writer, "SLOWEST CASES", report.SlowestCases); err != nil {
+		return err
+	}
+	if err := writeHistogramText(writer, "SUITE TIME DISTRIBUTION", report.SuiteHistogram); err != nil {
+		return err
+	}
+	if err := writeHistogramText(writer, "CASE TIME DISTRIBUTION", report.CaseHistogram); err != nil {
+		return err
+	}
+
+	table = tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "\nFILES\n")
+	fmt.Fprintf(table, "file\tsuites\tcases\ttime\n")
+	for _, file := range report.Files {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%.2fs\n", file.File, file.Suites, file.Cases, file.Time)
+	}
+	return table.Flush()
+}
+
+func writeGroupsText(writer io.Writer, title string, groups []Group) error {
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "\n%s\n", title)
+	fmt.Fprintf(table, "key\tmean\tmin\tmax\tsamples\n")
+	for _, group := range groups {
+		fmt.Fprintf(table, "%s\t%.2fs\t%.2fs\t%.2fs\t%d\n", group.Key, group.MeanTime, group.MinTime, group.MaxTime, group.Samples)
+	}
+	return table.Flush()
+}
+
+func writeHistogramText(writer io.Writer, title string, buckets []Bucket) error {
+	largest := 0
+	for _, bucket := range buckets {
+		if bucket.Count > largest {
+			largest = bucket.Count
+		}
+	}
+
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "\n%s\n", title)
+	for _, bucket := range buckets {
+		bar := ""
+		if largest > 0 {
+			bar = strings.Repeat("#", int(math.Ceil(float64(bucket.Count)/float64(largest)*40)))
+		}
+		fmt.Fprintf(table, "%s\t%d\t%s\n", bucketLabel(bucket), bucket.Count, bar)
+	}
+	return table.Flush()
+}
+
+func writeMarkdown(writer io.Writer, report Report) error {
+	out := &helpers.ErrWriter{Writer: writer}
+	totals := report.Totals
+
+	fmt.Fprintf(out, "## Totals\n\n| | |\n|---|---|\n")
+	fmt.Fprintf(out, "| Reports | %d |\n", totals.Reports)
+	fmt.Fprintf(out, "| Suites | %d (%d groups) |\n", totals.Suites, totals.SuiteGroups)
+	fmt.Fprintf(out, "| Cases | %d (%d groups) |\n", totals.Cases, totals.CaseGroups)
+	fmt.Fprintf(out, "| Time | %.2fs |\n", totals.Time)
+	fmt.Fprintf(out, "| Tests | %d (%d failed, %d errors, %d skipped) |\n", totals.Tests, totals.Failed, totals.Errors, totals.Skipped)
+	fmt.Fprintf(out, "| Samples per suite group | min %d, max %d, mean %.1f |\n", report.Samples.Min, report.Samples.Max, report.Samples.Mean)
+
+	writeGroupsMarkdown(out, "Slowest suites", report.SlowestSuites)
+	writeGroupsMarkdown(out, "Slowest cases", report.SlowestCases)
+	writeHistogramMarkdown(out, "Suite time distribution", report.SuiteHistogram)
+	writeHistogramMarkdown(out, "Case time distribution", report.CaseHistogram)
+
+	fmt.Fprintf(out, "\n## Files\n\n| File | Suites | Cases | Time |\n|---|---|---|---|\n")
+	for _, file := range report.Files {
+		fmt.Fprintf(out, "| `%s` | %d | %d | %.2fs |\n", file.File, file.Suites, file.Cases, file.Time)
+	}
+	return out.Err
+}
+
+func writeGroupsMarkdown(writer io.Writer, title string, groups []Group) {
+	fmt.Fprintf(writer, "\n## %s\n\n| Key | Mean | Min | Max | Samples |\n|---|---|---|---|---|\n", title)
+	for _, group := range groups {
+		fmt.Fprintf(writer, "| `%s` | %.2fs | %.2fs | %.2fs | %d |\n", group.Key, group.MeanTime, group.MinTime, group.MaxTime, group.Samples)
+	}
+}
+
+func writeHistogramMarkdown(writer io.Writer, title string, buckets []Bucket) {
+	fmt.Fprintf(writer, "\n## %s\n\n| Time | Count |\n|---|---|\n", title)
+	for _, bucket := range buckets {
+		fmt.Fprintf(writer, "| %s | %d |\n", bucketLabel(bucket), bucket.Count)
+	}
+}
@@ -0,0 +1,178 @@
+package stats
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+var testSuites = []serialization.TestSuite{
+	{
+		Name:     "UserTest",
+		File:     "test/user_test.rb",
+		FileName: "run-1.xml",
+		Time:     10,
+		Tests:    2,
+		Failed:   1,
+		TestCases: []serialization.TestCase{
+			{Name: "test_create", Time: 6},
+			{Name: "test_update", Time: 4},
+		},
+	},
+	{
+		Name:     "UserTest",
+		File:     "test/user_test.rb",
+		FileName: "run-2.xml",
+		Time:     20,
+		Tests:    2,
+		TestCases: []serialization.TestCase{
+			{Name: "test_create", Time: 14},
+			{Name: "test_update", Time: 6},
+		},
+	},
+	{
+		Name:     "AccountTest",
+		File:     "test/account_test.rb",
+		FileName: "run-1.xml",
+		Time:     0.05,
+		Tests:    1,
+		Skipped:  1,
+		TestCases: []serialization.TestCase{
+			{Name: "test_balance", Time: 0.05},
+		},
+	},
+}
+
+func TestSummarize(t *testing.T) {
+	report := Summarize(testSuites, enums.TestSuiteFieldFilepath, enums.TestCaseFieldName, 2)
+
+	expectedTotals := Totals{
+		Reports:     2,
+		Suites:      3,
+		Cases:       5,
+		SuiteGroups: 2,
+		CaseGroups:  3,
+		Time:        30.05,
+		Tests:       5,
+		Failed:      1,
+		Skipped:     1,
+	}
+
+	if report.Totals != expectedTotals {
+		t.Errorf("expected totals %+v, got %+v", expectedTotals, report.Totals)
+	}
+
+	expectedSamples := SampleCounts{Min: 1, Max: 2, Mean: 1.5}
+	if report.Samples != expectedSamples {
+		t.Errorf("expected sample counts %+v, got %+v", expectedSamples, report.Samples)
+	}
+
+	expectedSlowestSuite := Group{Key: "test/user_test.rb", Name: "UserTest", File: "test/user_test.rb", Samples: 2, MeanTime: 15, MinTime: 10, MaxTime: 20}
+	if report.SlowestSuites[0] != expectedSlowestSuite {
+		t.Errorf("expected slowest suite %+v, got %+v", expectedSlowestSuite, report.SlowestSuites[0])
+	}
+
+	if len(report.SlowestCases) != 2 {
+		t.Fatalf("expected the top 2 slowest cases, got %d", len(report.SlowestCases))
+	}
+
+	if report.SlowestCases[0].Key != "test/user_test.rb > test_create" || report.SlowestCases[0].MeanTime != 10 {
+		t.Errorf("expected slowest case 'test/user_test.rb > test_create' with a mean of 10s, got %+v", report.SlowestCases[0])
+	}
+
+	expectedFiles := []FileCount{
+		{File: "test/account_test.rb", Suites: 1, Cases: 1, Time: 0.05},
+		{File: "test/user_test.rb", Suites: 1, Cases: 2, Time: 15},
+	}
+	if !reflect.DeepEqual(report.Files, expectedFiles) {
+		t.Errorf("expected files %+v, got %+v", expectedFiles, report.Files)
+	}
+}
+
+func TestSummarizeWeighsReducedSuites(t *testing.T) {
+	// The two UserTest runs above, reduced to their means.
+	reducedSuites := []serialization.TestSuite{
+		{
+			Name:     "UserTest",
+			File:     "test/user_test.rb",
+			FileName: "run-1.xml",
+			Time:     15,
+			Tests:    2,
+			Samples:  2,
+			TestCases: []serialization.TestCase{
+				{Name: "test_create", Time: 10, Samples: 2},
+				{Name: "test_update", Time: 5, Samples: 2},
+			},
+		},
+	}
+
+	report := Summarize(reducedSuites, enums.TestSuiteFieldFilepath, enums.TestCaseFieldName, 2)
+
+	expectedTotals := Totals{Reports: 1, Suites: 2, Cases: 4, SuiteGroups: 1, CaseGroups: 2, Time: 30, Tests: 4}
+	if report.Totals != expectedTotals {
+		t.Errorf("expected totals %+v, got %+v", expectedTotals, report.Totals)
+	}
+
+	if report.SlowestSuites[0].Samples != 2 || report.SlowestSuites[0].MeanTime != 15 {
+		t.Errorf("expected the reduced suite to count as 2 samples with a mean of 15s, got %+v", report.SlowestSuites[0])
+	}
+	if report.SlowestCases[0].Samples != 2 || report.SlowestCases[0].MeanTime != 10 {
+		t.Errorf("expected the reduced case to count as 2 samples with a mean of 10s, got %+v", report.SlowestCases[0])
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	groups := []Group{{MeanTime: 0.05}, {MeanTime: 1}, {MeanTime: 4}, {MeanTime: 500}}
+
+	buckets := histogram(groups)
+	expectedCounts := []int{1, 0, 0, 2, 0, 0, 0, 0, 1}
+
+	for i, bucket := range buckets {
+		if bucket.Count != expectedCounts[i] {
+			t.Errorf("expected %d groups in bucket %s, got %d", expectedCounts[i], bucketLabel(bucket), bucket.Count)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	report := Summarize(testSuites, enums.TestSuiteFieldFilepath, enums.TestCaseFieldName, 10)
+
+	expectedOutputs := map[enums.ReportFormat]string{
+		enums.ReportFormatText:     "test/user_test.rb     15.00s  10.00s  20.00s  2",
+		enums.ReportFormatMarkdown: "| `test/user_test.rb` | 15.00s | 10.00s | 20.00s | 2 |",
+		enums.ReportFormatJSON:     `"suite_groups": 2`,
+	}
+
+	for format, expectedOutput := range expectedOutputs {
+		var output bytes.Buffer
+		err := Write(&output, report, format)
+		if err != nil {
+			t.Errorf("expected no error, got %s", err)
+		}
+		if !strings.Contains(output.String(), expectedOutput) {
+			t.Errorf("expected %s output to contain %q, got %q", enums.ReportFormatKeys[format], expectedOutput, output.String())
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriteReturnsWriteErrors(t *testing.T) {
+	report := Summarize(testSuites, enums.TestSuiteFieldFilepath, enums.TestCaseFieldName, 10)
+
+	for _, format := range []enums.ReportFormat{enums.ReportFormatText, enums.ReportFormatMarkdown, enums.ReportFormatJSON} {
+		err := Write(failingWriter{}, report, format)
+		if err == nil || err.Error() != "disk full" {
+			t.Errorf("expected %s output to return the write error, got %v", enums.ReportFormatKeys[format], err)
+		}
+	}
+}
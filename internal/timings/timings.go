@@ -0,0 +1,154 @@
+package timings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+// PytestDurations maps the pytest node id of every reduced case to its time,
+// in the shape of the .test_durations file pytest-split reads. Cases sharing
+// a node id keep the longest time.
+func PytestDurations(testSuites []serialization.TestSuite) map[string]float64 {
+	durations := make(map[string]float64)
+	for _, testSuite := range testSuites {
+		for _, testCase := range testSuite.TestCases {
+			file := testCase.File
+			if file == "" {
+				file = testSuite.File
+			}
+			nodeID := serialization.PytestNodeID(file, testCase.Classname, testCase.Name)
+			if time, ok := durations[nodeID]; !ok || testCase.Time > time {
+				durations[nodeID] = testCase.Time
+			}
+		}
+	}
+	return durations
+}
+
+// FileTimes sums the time of reduced suites per test file, the unit Ruby
+// runners balance by. Suites without a file attribute, like those written by
+// rspec_junit_formatter, count the time of their cases per case file instead.
+func FileTimes(testSuites []serialization.TestSuite) map[string]float64 {
+	times := make(map[string]float64)
+	for _, testSuite := range testSuites {
+		if testSuite.File != "" {
+			times[cleanFile(testSuite.File)] += testSuite.Time
+			continue
+		}
+		for _, testCase := range testSuite.TestCases {
+			if testCase.File != "" {
+				times[cleanFile(testCase.File)] += testCase.Time
+			}
+		}
+	}
+	return times
+}
+
+// failingFiles lists the test files with a failing or erroring reduced suite
+// or case.
+func failingFiles(testSuites []serialization.TestSuite) map[string]bool {
+	failing := make(map[string]bool)
+	for _, testSuite := range testSuites {
+		if testSuite.File != "" && testSuite.Failed+testSuite.Errors > 0 {
+			failing[cleanFile(testSuite.File)] = true
+		}
+		for _, testCase := range testSuite.TestCases {
+			file := testCase.File
+			if file == "" {
+				file = testSuite.File
+			}
+			outcomes := serialization.CaseOutcomes(testCase)
+			if file != "" && outcomes.Failed+outcomes.Errored > 0 {
+				failing[cleanFile(file)] = true
+			}
+		}
+	}
+	return failing
+}
+
+// Statuses of a test file in Jest's perf cache.
+const (
+	jestFailed  = 0
+	jestSuccess = 1
+)
+
+// JestCache maps test files to the [status, milliseconds] pairs Jest keeps in
+// its perf cache, which its default test sequencer orders by, failing files
+// first and then slowest first. Like Jest, the status is 0 for files that
+// failed and 1 for files that passed.
+func JestCache(testSuites []serialization.TestSuite) map[string][2]int64 {
+	failing := failingFiles(testSuites)
+	cache := make(map[string][2]int64)
+	for file, time := range FileTimes(testSuites) {
+		status := int64(jestSuccess)
+		if failing[file] {
+			status = jestFailed
+		}
+		cache[file] = [2]int64{status, milliseconds(time)}
+	}
+	return cache
+}
+
+// PlaywrightDurations maps test files to milliseconds, for balancing
+// Playwright shards.
+func PlaywrightDurations(testSuites []serialization.TestSuite) map[string]int64 {
+	durations := make(map[string]int64)
+	for file, time := range FileTimes(testSuites) {
+		durations[file] = milliseconds(time)
+	}
+	return durations
+}
+
+func milliseconds(seconds float64) int64 {
+	return int64(math.Round(seconds * 1000))
+}
+
+func cleanFile(file string) string {
+	return strings.TrimPrefix(path.Clean(strings.ReplaceAll(file, "\\", "/")), "./")
+}
+
+// writeRuntimeLog writes a parallel_tests runtime log, a "file:seconds" line
+// per file.
+func writeRuntimeLog(writer io.Writer, times map[string]float64) error {
+	files := make([]string, 0, len(times))
+	for file := range times {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		if _, err := fmt.Fprintf(writer, "%s:%s\n", file, strconv.FormatFloat(times[file], 'f', -1, 64)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(writer io.Writer, times interface{}) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(times)
+}
+
+func Write(writer io.Writer, testSuites []serialization.TestSuite, format enums.TimingsFormat) error {
+	switch format {
+	case enums.TimingsFormatParallelTests:
+		return writeRuntimeLog(writer, FileTimes(testSuites))
+	case enums.TimingsFormatKnapsack:
+		return writeJSON(writer, FileTimes(testSuites))
+	case enums.TimingsFormatJest:
+		return writeJSON(writer, JestCache(testSuites))
+	case enums.TimingsFormatPlaywright:
+		return writeJSON(writer, PlaywrightDurations(testSuites))
+	}
+	return writeJSON(writer, PytestDurations(testSuites))
+}
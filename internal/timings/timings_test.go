@@ -0,0 +1,140 @@
+package timings
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+	"github.com/willgeorgetaylor/junit-reducer/internal/serialization"
+)
+
+var reducedSuites = []serialization.TestSuite{
+	{
+		Name: "tests.test_user.TestUser",
+		File: "tests/test_user.py",
+		TestCases: []serialization.TestCase{
+			{Name: "test_create", Classname: "tests.test_user.TestUser", Time: 1.5},
+			{Name: "test_update[admin]", Classname: "tests.test_user.TestUser", Time: 0.25},
+		},
+	},
+	{
+		Name: "tests.test_session",
+		TestCases: []serialization.TestCase{
+			{Name: "test_login", Classname: "tests.test_session", Time: 2},
+			{Name: "test_login", Classname: "tests.test_session", Time: 3},
+		},
+	},
+}
+
+func TestPytestDurations(t *testing.T) {
+	expected := map[string]float64{
+		"tests/test_user.py::TestUser::test_create":        1.5,
+		"tests/test_user.py::TestUser::test_update[admin]": 0.25,
+		"tests/test_session.py::test_login":                3,
+	}
+
+	actual := PytestDurations(reducedSuites)
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, but got %v", expected, actual)
+	}
+}
+
+func TestWritePytest(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := Write(&buffer, reducedSuites[1:], enums.TimingsFormatPytest); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "{\n  \"tests/test_session.py::test_login\": 3\n}\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, buffer.String())
+	}
+}
+
+var rubySuites = []serialization.TestSuite{
+	{Name: "User", File: "./spec/models/user_spec.rb", Time: 2.5},
+	{Name: "User validations", File: "spec/models/user_spec.rb", Time: 1},
+	{
+		Name: "rspec",
+		TestCases: []serialization.TestCase{
+			{Name: "creates a session", File: "./spec/requests/session_spec.rb", Time: 0.75},
+			{Name: "destroys a session", File: "./spec/requests/session_spec.rb", Time: 0.5},
+		},
+	},
+}
+
+func TestFileTimes(t *testing.T) {
+	expected := map[string]float64{
+		"spec/models/user_spec.rb":      3.5,
+		"spec/requests/session_spec.rb": 1.25,
+	}
+
+	actual := FileTimes(rubySuites)
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, but got %v", expected, actual)
+	}
+}
+
+func TestWriteParallelTests(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := Write(&buffer, rubySuites, enums.TimingsFormatParallelTests); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "spec/models/user_spec.rb:3.5\nspec/requests/session_spec.rb:1.25\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, buffer.String())
+	}
+}
+
+func TestWriteKnapsack(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := Write(&buffer, rubySuites[:2], enums.TimingsFormatKnapsack); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "{\n  \"spec/models/user_spec.rb\": 3.5\n}\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, buffer.String())
+	}
+}
+
+var frontEndSuites = []serialization.TestSuite{
+	{Name: "Button", File: "src/button.test.tsx", Time: 1.2344},
+	{Name: "Form", File: "src/form.test.tsx", Time: 0.5, Failed: 1},
+	{
+		Name: "checkout.spec.ts",
+		TestCases: []serialization.TestCase{
+			{Name: "pays", File: "e2e/checkout.spec.ts", Time: 4, Outcomes: serialization.Outcomes{Passed: 1, Errored: 1}},
+		},
+	},
+}
+
+func TestJestCache(t *testing.T) {
+	expected := map[string][2]int64{
+		"src/button.test.tsx":  {1, 1234},
+		"src/form.test.tsx":    {0, 500},
+		"e2e/checkout.spec.ts": {0, 4000},
+	}
+
+	actual := JestCache(frontEndSuites)
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, but got %v", expected, actual)
+	}
+}
+
+func TestWritePlaywright(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := Write(&buffer, frontEndSuites[:1], enums.TimingsFormatPlaywright); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "{\n  \"src/button.test.tsx\": 1234\n}\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, buffer.String())
+	}
+}
@@ -0,0 +1,358 @@
+package validator
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+const (
+	RuleUnparseable      = "unparseable"
+	RuleUnexpectedRoot   = "unexpected-root"
+	RuleMissingAttribute = "missing-attribute"
+	RuleInvalidNumber    = "invalid-number"
+	RuleNegativeValue    = "negative-value"
+	RuleCountMismatch    = "count-mismatch"
+	RuleDuplicateCase    = "duplicate-case"
+)
+
+// Issue is a single problem found in a report file. Suite and Case are empty
+// when the issue concerns the whole file or suite.
+type Issue struct {
+	File     string `json:"file"`
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	Suite    string `json:"suite,omitempty"`
+	Case     string `json:"case,omitempty"`
+	Message  string `json:"message"`
+}
+
+type Report struct {
+	Valid  bool   `json:"valid"`
+	Schema string `json:"schema"`
+	Files  int    `json:"files"`
+	// Files with errors, or with warnings too when strict
+	InvalidFiles int     `json:"invalid_files"`
+	Errors       int     `json:"errors"`
+	Warnings     int     `json:"warnings"`
+	Issues       []Issue `json:"issues"`
+}
+
+// requiredAttributes lists the attributes each schema requires on suites and
+// cases. Ant covers the Ant and Maven Surefire reports, Jenkins only needs
+// enough to show a result, and GitLab keys its test reports on the case
+// classname and name.
+var requiredAttributes = map[enums.JUnitSchema]struct {
+	suite []string
+	cases []string
+}{
+	enums.JUnitSchemaAnt: {
+		suite: []string{"name", "tests", "failures", "errors", "time"},
+		cases: []string{"name", "classname", "time"},
+	},
+	enums.JUnitSchemaJenkins: {
+		suite: []string{"name", "tests"},
+		cases: []string{"name"},
+	},
+	enums.JUnitSchemaGitLab: {
+		suite: []string{},
+		cases: []string{"name", "classname"},
+	},
+}
+
+var countAttributes = []string{"tests", "failures", "errors", "skipped"}
+
+// attributeAliases are read in place of a missing attribute, like the failed
+// count that junit-reducer writes instead of failures.
+var attributeAliases = map[string][]string{
+	"failures": {"failed"},
+}
+
+type rawElement struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr   `xml:",any,attr"`
+	Suites  []rawElement `xml:"testsuite"`
+	Cases   []rawCase    `xml:"testcase"`
+}
+
+type rawCase struct {
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Failures []rawChild `xml:"failure"`
+	Errors   []rawChild `xml:"error"`
+	Skipped  []rawChild `xml:"skipped"`
+}
+
+type rawChild struct {
+	XMLName xml.Name
+}
+
+func attribute(attrs []xml.Attr, name string) (string, bool) {
+	for _, attr := range attrs {
+		if attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// aliasedAttribute looks up an attribute, falling back to its aliases.
+func aliasedAttribute(attrs []xml.Attr, name string) (string, bool) {
+	if value, ok := attribute(attrs, name); ok {
+		return value, true
+	}
+	for _, alias := range attributeAliases[name] {
+		if value, ok := attribute(attrs, alias); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+type fileValidator struct {
+	file     string
+	required []string
+	cases    []string
+	issues   []Issue
+}
+
+func (validator *fileValidator) add(severity string, rule string, suite string, testCase string, format string, args ...interface{}) {
+	validator.issues = append(validator.issues, Issue{
+		File:     validator.file,
+		Severity: severity,
+		Rule:     rule,
+		Suite:    suite,
+		Case:     testCase,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// checkAttributes reports missing required attributes and malformed numbers,
+// returning the counts that could be read.
+func (validator *fileValidator) checkAttributes(attrs []xml.Attr, required []string, suite string, testCase string) map[string]int {
+	for _, name := range required {
+		if _, ok := aliasedAttribute(attrs, name); !ok {
+			validator.add(SeverityError, RuleMissingAttribute, suite, testCase, "missing required attribute '%s'", name)
+		}
+	}
+
+	if value, ok := attribute(attrs, "time"); ok {
+		time, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			validator.add(SeverityError, RuleInvalidNumber, suite, testCase, "time '%s' is not a number", value)
+		} else if time < 0 {
+			validator.add(SeverityError, RuleNegativeValue, suite, testCase, "time %s is negative", value)
+		}
+	}
+
+	counts := make(map[string]int)
+	if testCase != "" {
+		return counts
+	}
+	for _, name := range countAttributes {
+		value, ok := aliasedAttribute(attrs, name)
+		if !ok {
+			continue
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			validator.add(SeverityError, RuleInvalidNumber, suite, testCase, "%s '%s' is not a whole number", name, value)
+			continue
+		}
+		if count < 0 {
+			validator.add(SeverityError, RuleNegativeValue, suite, testCase, "%s %d is negative", name, count)
+			continue
+		}
+		counts[name] = count
+	}
+	return counts
+}
+
+func (validator *fileValidator) checkCounts(declared map[string]int, actual map[string]int, suite string, scope string) {
+	for _, name := range countAttributes {
+		count, ok := declared[name]
+		if ok && count != actual[name] {
+			validator.add(SeverityError, RuleCountMismatch, suite, "", "%s declares %s=%d but contains %d", scope, name, count, actual[name])
+		}
+	}
+}
+
+// checkSuite validates a suite and its nested suites, returning the number
+// of cases, failures, errors and skips found inside it.
+func (validator *fileValidator) checkSuite(suite rawElement) map[string]int {
+	name, _ := attribute(suite.Attrs, "name")
+	label := name
+	if label == "" {
+		label = "(unnamed)"
+	}
+
+	declared := validator.checkAttributes(suite.Attrs, validator.required, label, "")
+
+	actual := make(map[string]int)
+	for _, nested := range suite.Suites {
+		for key, count := range validator.checkSuite(nested) {
+			actual[key] += count
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, testCase := range suite.Cases {
+		caseName, _ := attribute(testCase.Attrs, "name")
+		classname, _ := attribute(testCase.Attrs, "classname")
+		caseLabel := caseName
+		if caseLabel == "" {
+			caseLabel = "(unnamed)"
+		}
+
+		validator.checkAttributes(testCase.Attrs, validator.cases, label, caseLabel)
+
+		identity := classname + "\x00" + caseName
+		if seen[identity] {
+			validator.add(SeverityWarning, RuleDuplicateCase, label, caseLabel, "case '%s' of class '%s' appears more than once", caseName, classname)
+		}
+		seen[identity] = true
+
+		actual["tests"]++
+		if len(testCase.Failures) > 0 {
+			actual["failures"]++
+		}
+		if len(testCase.Errors) > 0 {
+			actual["errors"]++
+		}
+		if len(testCase.Skipped) > 0 {
+			actual["skipped"]++
+		}
+	}
+
+	// Reduced suites declare the mean counts of the runs they were reduced
+	// from, which the cases they contain can't add up to, so their declared
+	// counts are taken as they are.
+	if _, reduced := attribute(suite.Attrs, "samples"); reduced {
+		return declared
+	}
+
+	validator.checkCounts(declared, actual, label, "suite")
+	return actual
+}
+
+// ValidateReader checks a single report against the schema, returning the
+// issues found in it.
+func ValidateReader(reader io.Reader, file string, schema enums.JUnitSchema) []Issue {
+	validator := &fileValidator{
+		file:     file,
+		required: requiredAttributes[schema].suite,
+		cases:    requiredAttributes[schema].cases,
+		issues:   []Issue{},
+	}
+
+	var root rawElement
+	if err := xml.NewDecoder(reader).Decode(&root); err != nil {
+		validator.add(SeverityError, RuleUnparseable, "", "", "failed to parse XML: %v", err)
+		return validator.issues
+	}
+
+	switch root.XMLName.Local {
+	case "testsuites":
+		declared := validator.checkAttributes(root.Attrs, nil, "", "")
+		actual := make(map[string]int)
+		for _, suite := range root.Suites {
+			for key, count := range validator.checkSuite(suite) {
+				actual[key] += count
+			}
+		}
+		validator.checkCounts(declared, actual, "", "testsuites")
+	case "testsuite":
+		validator.checkSuite(root)
+	default:
+		validator.add(SeverityError, RuleUnexpectedRoot, "", "", "root element is <%s>, expected <testsuites> or <testsuite>", root.XMLName.Local)
+	}
+
+	return validator.issues
+}
+
+// Validate checks every report file against the schema. The report is
+// invalid when any errors are found, or any warnings too when strict.
+func Validate(paths []string, schema enums.JUnitSchema, strict bool) (Report, error) {
+	report := Report{Schema: enums.JUnitSchemaKeys[schema], Issues: []Issue{}}
+
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return report, err
+		}
+		issues := ValidateReader(file, path, schema)
+		file.Close()
+
+		report.Files++
+		report.Issues = append(report.Issues, issues...)
+	}
+
+	invalidFiles := make(map[string]bool)
+	for _, issue := range report.Issues {
+		if issue.Severity == SeverityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+		if issue.Severity == SeverityError || strict {
+			invalidFiles[issue.File] = true
+		}
+	}
+	report.InvalidFiles = len(invalidFiles)
+	report.Valid = report.InvalidFiles == 0
+
+	return report, nil
+}
+
+func issueLocation(issue Issue) string {
+	location := issue.File
+	if issue.Suite != "" {
+		location += ": " + issue.Suite
+	}
+	if issue.Case != "" {
+		location += " > " + issue.Case
+	}
+	return location
+}
+
+func Write(writer io.Writer, report Report, format enums.ReportFormat) error {
+	if format == enums.ReportFormatJSON {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	result := fmt.Sprintf("%d files valid", report.Files)
+	if !report.Valid {
+		result = fmt.Sprintf("%d of %d files invalid", report.InvalidFiles, report.Files)
+	}
+
+	if format == enums.ReportFormatMarkdown {
+		fmt.Fprintf(writer, "## JUnit validation (%s)\n\n", report.Schema)
+		fmt.Fprintf(writer, "%s: %d errors, %d warnings.\n", result, report.Errors, report.Warnings)
+		if len(report.Issues) == 0 {
+			return nil
+		}
+		fmt.Fprintf(writer, "\n| Severity | Rule | Location | Message |\n|---|---|---|---|\n")
+		for _, issue := range report.Issues {
+			fmt.Fprintf(writer, "| %s | %s | `%s` | %s |\n", issue.Severity, issue.Rule, issueLocation(issue), issue.Message)
+		}
+		return nil
+	}
+
+	for _, issue := range report.Issues {
+		fmt.Fprintf(writer, "%s: %s [%s] %s\n", issueLocation(issue), issue.Severity, issue.Rule, issue.Message)
+	}
+	_, err := fmt.Fprintf(writer, "%s against the %s schema: %d errors, %d warnings\n", result, report.Schema, report.Errors, report.Warnings)
+	return err
+}
@@ -0,0 +1,262 @@
+package validator
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/willgeorgetaylor/junit-reducer/internal/enums"
+)
+
+const validReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="3" failures="1" errors="0">
+  <testsuite name="UserTest" tests="2" failures="1" errors="0" skipped="0" time="1.5">
+    <testcase name="test_create" classname="UserTest" time="1.0"/>
+    <testcase name="test_update" classname="UserTest" time="0.5">
+      <failure message="expected true"/>
+    </testcase>
+  </testsuite>
+  <testsuite name="AccountTest" tests="1" failures="0" errors="0" time="0.2">
+    <testcase name="test_show" classname="AccountTest" time="0.2"/>
+  </testsuite>
+</testsuites>`
+
+func rules(issues []Issue) []string {
+	result := []string{}
+	for _, issue := range issues {
+		result = append(result, issue.Rule)
+	}
+	return result
+}
+
+func TestValidReport(t *testing.T) {
+	for _, schema := range enums.JUnitSchemaValues {
+		issues := ValidateReader(strings.NewReader(validReport), "valid.xml", schema)
+		if len(issues) != 0 {
+			t.Errorf("Expected no issues for schema %s, but got %v", enums.JUnitSchemaKeys[schema], issues)
+		}
+	}
+}
+
+func TestSingleSuiteRoot(t *testing.T) {
+	report := `<testsuite name="UserTest" tests="1" failures="0" errors="0" time="1">
+  <testcase name="test_create" classname="UserTest" time="1"/>
+</testsuite>`
+
+	issues := ValidateReader(strings.NewReader(report), "suite.xml", enums.JUnitSchemaAnt)
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues, but got %v", issues)
+	}
+}
+
+func TestMissingAttributesDependOnSchema(t *testing.T) {
+	report := `<testsuites><testsuite name="UserTest" tests="1">
+  <testcase name="test_create"/>
+</testsuite></testsuites>`
+
+	tests := []struct {
+		schema   enums.JUnitSchema
+		expected []string
+	}{
+		{enums.JUnitSchemaAnt, []string{
+			"failures", "errors", "time", "classname", "time",
+		}},
+		{enums.JUnitSchemaJenkins, []string{}},
+		{enums.JUnitSchemaGitLab, []string{"classname"}},
+	}
+
+	for _, test := range tests {
+		issues := ValidateReader(strings.NewReader(report), "report.xml", test.schema)
+		missing := []string{}
+		for _, issue := range issues {
+			if issue.Rule != RuleMissingAttribute {
+				t.Errorf("Expected only missing attributes, but got %v", issue)
+			}
+			missing = append(missing, strings.Trim(strings.TrimPrefix(issue.Message, "missing required attribute "), "'"))
+		}
+		if !reflect.DeepEqual(missing, test.expected) {
+			t.Errorf("Expected missing %v for schema %s, but got %v", test.expected, enums.JUnitSchemaKeys[test.schema], missing)
+		}
+	}
+}
+
+func TestInvalidNumbers(t *testing.T) {
+	report := `<testsuite name="UserTest" tests="two" time="-1">
+  <testcase name="test_create" classname="UserTest" time="fast"/>
+  <testcase name="test_update" classname="UserTest" time="-0.5"/>
+</testsuite>`
+
+	issues := ValidateReader(strings.NewReader(report), "report.xml", enums.JUnitSchemaJenkins)
+
+	expected := []string{RuleNegativeValue, RuleInvalidNumber, RuleInvalidNumber, RuleNegativeValue}
+	if !reflect.DeepEqual(rules(issues), expected) {
+		t.Errorf("Expected rules %v, but got %v", expected, issues)
+	}
+	if issues[2].Case != "test_create" || issues[2].Suite != "UserTest" {
+		t.Errorf("Expected the issue to locate the case, but got %v", issues[2])
+	}
+}
+
+func TestCountMismatch(t *testing.T) {
+	report := `<testsuites tests="5">
+  <testsuite name="UserTest" tests="3" failures="0" skipped="1">
+    <testcase name="test_create" classname="UserTest">
+      <failure/>
+    </testcase>
+    <testcase name="test_update" classname="UserTest">
+      <skipped/>
+    </testcase>
+  </testsuite>
+</testsuites>`
+
+	issues := ValidateReader(strings.NewReader(report), "report.xml", enums.JUnitSchemaJenkins)
+
+	messages := []string{}
+	for _, issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+	expected := []string{
+		"suite declares tests=3 but contains 2",
+		"suite declares failures=0 but contains 1",
+		"testsuites declares tests=5 but contains 2",
+	}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("Expected messages %v, but got %v", expected, messages)
+	}
+}
+
+func TestFailedCountsAsFailures(t *testing.T) {
+	// Reduced reports write failed in place of failures.
+	report := `<testsuites>
+  <testsuite name="UserTest" filepath="test/user_test.rb" time="1.5" tests="2" failed="1" errors="0" skipped="0" assertions="0">
+    <testcase name="test_create" classname="UserTest" file="" lineno="0" time="1" assertions="0"></testcase>
+    <testcase name="test_update" classname="UserTest" file="" lineno="0" time="0.5" assertions="0">
+      <failure message="expected true"></failure>
+    </testcase>
+  </testsuite>
+</testsuites>`
+
+	issues := ValidateReader(strings.NewReader(report), "report.xml", enums.JUnitSchemaAnt)
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues, but got %v", issues)
+	}
+
+	mismatched := strings.Replace(report, `failed="1"`, `failed="2"`, 1)
+	issues = ValidateReader(strings.NewReader(mismatched), "report.xml", enums.JUnitSchemaAnt)
+	if len(issues) != 1 || issues[0].Message != "suite declares failures=2 but contains 1" {
+		t.Errorf("Expected a failures count mismatch, but got %v", issues)
+	}
+}
+
+func TestReducedReport(t *testing.T) {
+	for _, schema := range enums.JUnitSchemaValues {
+		report, err := Validate([]string{"fixtures/reduced.xml"}, schema, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !report.Valid {
+			t.Errorf("Expected a reduced report to be valid against schema %s, but got %v", enums.JUnitSchemaKeys[schema], report.Issues)
+		}
+	}
+}
+
+func TestNestedSuitesCountTowardsParent(t *testing.T) {
+	report := `<testsuite name="All" tests="2">
+  <testsuite name="UserTest" tests="1">
+    <testcase name="test_create" classname="UserTest"/>
+  </testsuite>
+  <testcase name="test_show" classname="AccountTest"/>
+</testsuite>`
+
+	issues := ValidateReader(strings.NewReader(report), "report.xml", enums.JUnitSchemaGitLab)
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues, but got %v", issues)
+	}
+}
+
+func TestDuplicateCase(t *testing.T) {
+	report := `<testsuite name="UserTest" tests="3">
+  <testcase name="test_create" classname="UserTest"/>
+  <testcase name="test_create" classname="UserTest"/>
+  <testcase name="test_create" classname="AdminTest"/>
+</testsuite>`
+
+	issues := ValidateReader(strings.NewReader(report), "report.xml", enums.JUnitSchemaGitLab)
+
+	if len(issues) != 1 || issues[0].Rule != RuleDuplicateCase || issues[0].Severity != SeverityWarning {
+		t.Errorf("Expected one duplicate case warning, but got %v", issues)
+	}
+}
+
+func TestUnparseableAndUnexpectedRoot(t *testing.T) {
+	issues := ValidateReader(strings.NewReader("<testsuite"), "broken.xml", enums.JUnitSchemaAnt)
+	if !reflect.DeepEqual(rules(issues), []string{RuleUnparseable}) {
+		t.Errorf("Expected an unparseable error, but got %v", issues)
+	}
+
+	issues = ValidateReader(strings.NewReader("<results/>"), "other.xml", enums.JUnitSchemaAnt)
+	if !reflect.DeepEqual(rules(issues), []string{RuleUnexpectedRoot}) {
+		t.Errorf("Expected an unexpected root error, but got %v", issues)
+	}
+}
+
+func TestValidateStrict(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.xml")
+	report := `<testsuite name="UserTest" tests="2">
+  <testcase name="test_create" classname="UserTest"/>
+  <testcase name="test_create" classname="UserTest"/>
+</testsuite>`
+	if err := os.WriteFile(path, []byte(report), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lenient, err := Validate([]string{path}, enums.JUnitSchemaGitLab, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lenient.Valid || lenient.Files != 1 || lenient.InvalidFiles != 0 || lenient.Warnings != 1 || lenient.Errors != 0 {
+		t.Errorf("Expected a valid report with one warning, but got %+v", lenient)
+	}
+
+	strict, err := Validate([]string{path}, enums.JUnitSchemaGitLab, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strict.Valid || strict.InvalidFiles != 1 {
+		t.Errorf("Expected warnings to invalidate a strict report, but got %+v", strict)
+	}
+}
+
+func TestValidateMissingFile(t *testing.T) {
+	_, err := Validate([]string{filepath.Join(t.TempDir(), "missing.xml")}, enums.JUnitSchemaAnt, false)
+	if err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	report := Report{
+		Schema:       "ant",
+		Files:        4,
+		InvalidFiles: 1,
+		Errors:       1,
+		Issues: []Issue{
+			{File: "report.xml", Severity: SeverityError, Rule: RuleNegativeValue, Suite: "UserTest", Case: "test_create", Message: "time -1 is negative"},
+		},
+	}
+
+	var buffer bytes.Buffer
+	if err := Write(&buffer, report, enums.ReportFormatText); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "report.xml: UserTest > test_create: error [negative-value] time -1 is negative\n" +
+		"1 of 4 files invalid against the ant schema: 1 errors, 0 warnings\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected output %q, but got %q", expected, buffer.String())
+	}
+}